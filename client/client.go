@@ -0,0 +1,278 @@
+// Package client 实现节点侧的能力
+// 节点的 wg 接口需要已经按照注册时返回的配置启动，客户端从服务端获取节点的配置并维护经过 wg 接口的路由
+// 注册了多个中继节点时，客户端根据握手情况在本地切换中继节点并通知服务端
+package client
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/onesaltedseafish/go-utils/log"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// leaseRecheckInterval 租约不会过期时重新续租的间隔，服务端开启租约之后可以及时续租
+const leaseRecheckInterval = time.Minute
+
+// Operator 节点侧对 wg 设备以及路由的操作，测试时可以替换
+type Operator interface {
+	// GetPeers 获取 wg 设备上所有的节点
+	GetPeers(interfaceName string) ([]wgtypes.Peer, error)
+	// ConfigurePeers 修改 wg 设备上的节点
+	ConfigurePeers(interfaceName string, peers []wgtypes.PeerConfig) error
+	// AddRoutes 添加经过 wg 接口的路由
+	AddRoutes(config wg.RouteConfig, networks []net.IPNet) error
+	// DelRoutes 删除经过 wg 接口的路由
+	DelRoutes(config wg.RouteConfig, networks []net.IPNet) error
+	// GetRoutes 获取 wg-tool 在 wg 接口上添加的路由
+	GetRoutes(config wg.RouteConfig) ([]net.IPNet, error)
+}
+
+// KernelOperator 操作内核中的 wg 设备以及路由表
+type KernelOperator struct{}
+
+// GetPeers 获取 wg 设备上所有的节点
+func (KernelOperator) GetPeers(interfaceName string) ([]wgtypes.Peer, error) {
+	return wg.GetWgPeers(interfaceName)
+}
+
+// ConfigurePeers 修改 wg 设备上的节点
+func (KernelOperator) ConfigurePeers(interfaceName string, peers []wgtypes.PeerConfig) error {
+	return wg.ConfigureWgPeers(interfaceName, peers)
+}
+
+// AddRoutes 添加经过 wg 接口的路由
+func (KernelOperator) AddRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	return wg.AddRoutes(config, networks)
+}
+
+// DelRoutes 删除经过 wg 接口的路由
+func (KernelOperator) DelRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	return wg.DelRoutes(config, networks)
+}
+
+// GetRoutes 获取 wg-tool 在 wg 接口上添加的路由
+func (KernelOperator) GetRoutes(config wg.RouteConfig) ([]net.IPNet, error) {
+	return wg.GetRoutes(config)
+}
+
+// Client 节点侧的客户端
+type Client struct {
+	rpc      pb.WireguardToolClient
+	logger   *log.Logger
+	operator Operator
+	peerName string         // 节点名
+	route    wg.RouteConfig // 经过 wg 接口的路由配置
+	timeout  time.Duration  // 中继节点的握手超时时长，为 0 时不切换中继节点
+	failover *wg.RelayFailover
+	relays   map[wgtypes.Key]string // 中继节点的公钥以及节点名
+	renewAt  time.Time              // 下一次续租的时间
+}
+
+// NewClient 初始化节点侧的客户端
+func NewClient(rpc pb.WireguardToolClient, logger *log.Logger, peerName, interfaceName string) *Client {
+	return &Client{
+		rpc:      rpc,
+		logger:   logger,
+		operator: KernelOperator{},
+		peerName: peerName,
+		route:    wg.RouteConfig{InterfaceName: interfaceName},
+	}
+}
+
+// WithOperator 替换对 wg 设备以及路由的操作
+func (c *Client) WithOperator(operator Operator) *Client {
+	c.operator = operator
+	return c
+}
+
+// WithRouteConfig 设置路由所在的路由表以及路由的优先级
+func (c *Client) WithRouteConfig(table, metric int) *Client {
+	c.route.Table = table
+	c.route.Metric = metric
+	return c
+}
+
+// WithFailover 设置中继节点的握手超时时长，当前的中继节点超时没有握手时切换到其他中继节点
+// 超时时长需要大于 wg 重新握手的间隔，为 0 时不切换
+func (c *Client) WithFailover(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// Sync 从服务端获取节点的配置，并同步经过 wg 接口的路由以及当前使用的中继节点
+func (c *Client) Sync(ctx context.Context) (*pb.PeerConfigRsp, error) {
+	rsp, err := c.rpc.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: c.peerName})
+	if err != nil {
+		return nil, err
+	}
+	if err = c.updateFailover(rsp); err != nil {
+		return rsp, err
+	}
+	if _, err = c.SyncRoutes(ctx, rsp); err != nil {
+		return rsp, err
+	}
+	return rsp, nil
+}
+
+// CheckRelay 检查当前使用的中继节点的握手情况，超时时在本地切换到其他中继节点并通知服务端
+// 切换之后返回 true，通知服务端失败时下一次同步配置之后重新切换
+func (c *Client) CheckRelay(ctx context.Context, now time.Time) (bool, error) {
+	if c.failover == nil {
+		return false, nil
+	}
+	previous := c.relays[c.failover.Active()]
+	switched, err := c.failover.Check(now)
+	if err != nil || !switched {
+		return false, err
+	}
+	relay := c.relays[c.failover.Active()]
+	c.logger.Warn(ctx, "switch relay", zap.String("from", previous), zap.String("to", relay))
+	rsp, err := c.rpc.SwitchRelay(ctx, &pb.SwitchRelayReq{PeerName: c.peerName, RelayName: relay})
+	if err != nil {
+		return true, err
+	}
+	if err = c.updateFailover(rsp); err != nil {
+		return true, err
+	}
+	_, err = c.SyncRoutes(ctx, rsp)
+	return true, err
+}
+
+// RenewLease 到达续租时间时向服务端续租节点的地址，在剩余租约时长的一半时再次续租
+// 续租之后返回新的到期时间，租约不会过期时到期时间为零值，间隔 leaseRecheckInterval 之后重新续租
+func (c *Client) RenewLease(ctx context.Context, now time.Time) (time.Time, error) {
+	if now.Before(c.renewAt) {
+		return time.Time{}, nil
+	}
+	rsp, err := c.rpc.RenewLease(ctx, &pb.RenewLeaseReq{PeerName: c.peerName})
+	if err != nil {
+		return time.Time{}, err
+	}
+	if rsp.GetExpireTime() == 0 {
+		c.renewAt = now.Add(leaseRecheckInterval)
+		return time.Time{}, nil
+	}
+	expireTime := time.Unix(rsp.GetExpireTime(), 0)
+	c.renewAt = now.Add(expireTime.Sub(now) / 2)
+	c.logger.Debug(ctx, "renew lease", zap.Time("expire", expireTime), zap.Time("next", c.renewAt))
+	return expireTime, nil
+}
+
+// updateFailover 按照节点的配置更新中继节点的优先级以及当前使用的中继节点
+// 服务端按照优先级返回中继节点，只有当前使用的中继节点有 AllowedIPs
+func (c *Client) updateFailover(rsp *pb.PeerConfigRsp) error {
+	if c.timeout <= 0 {
+		return nil
+	}
+	var keys []wgtypes.Key
+	var active wgtypes.Key
+	var allowedIPs []net.IPNet
+	relays := make(map[wgtypes.Key]string)
+	for _, peer := range rsp.GetPeers() {
+		if !peer.GetIsRelay() {
+			continue
+		}
+		key, err := wgtypes.ParseKey(peer.GetPubkey())
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+		relays[key] = peer.GetPeerName()
+		if len(peer.GetAllowedIps()) == 0 || len(allowedIPs) > 0 {
+			continue
+		}
+		active = key
+		for _, allowed := range peer.GetAllowedIps() {
+			_, network, err := net.ParseCIDR(allowed.GetAddress())
+			if err != nil {
+				return err
+			}
+			allowedIPs = append(allowedIPs, *network)
+		}
+	}
+	// 只有一个中继节点时不需要切换
+	if len(keys) < 2 {
+		c.failover, c.relays = nil, nil
+		return nil
+	}
+	c.failover = wg.NewRelayFailover(c.route.InterfaceName, keys, allowedIPs, c.timeout).
+		WithDevice(c.operator.GetPeers, c.operator.ConfigurePeers)
+	c.failover.SetActive(active)
+	c.relays = relays
+	return nil
+}
+
+// SyncRoutes 使 wg 接口上的路由与节点的配置保持一致
+func (c *Client) SyncRoutes(ctx context.Context, rsp *pb.PeerConfigRsp) (wg.RouteDiff, error) {
+	var diff wg.RouteDiff
+	desired, err := PeerRoutes(rsp)
+	if err != nil {
+		return diff, err
+	}
+	actual, err := c.operator.GetRoutes(c.route)
+	if err != nil {
+		return diff, err
+	}
+	diff = wg.DiffRoutes(desired, actual)
+	if len(diff.Add) > 0 {
+		if err = c.operator.AddRoutes(c.route, diff.Add); err != nil {
+			return diff, err
+		}
+		c.logger.Info(ctx, "add routes", zap.String("interface", c.route.InterfaceName),
+			zap.Stringers("routes", networkStringers(diff.Add)))
+	}
+	if len(diff.Remove) > 0 {
+		if err = c.operator.DelRoutes(c.route, diff.Remove); err != nil {
+			return diff, err
+		}
+		c.logger.Info(ctx, "remove routes", zap.String("interface", c.route.InterfaceName),
+			zap.Stringers("routes", networkStringers(diff.Remove)))
+	}
+	return diff, nil
+}
+
+// PeerRoutes 节点需要经过 wg 接口转发的路由
+// 包括所有节点的 AllowedIPs 中不在覆盖网络中的地址，覆盖网络由 wg 接口的地址直接路由
+func PeerRoutes(rsp *pb.PeerConfigRsp) ([]net.IPNet, error) {
+	var overlays []inet.CidrAddress
+	for _, address := range []*pb.CidrAddress{rsp.GetAddress(), rsp.GetAddress6()} {
+		if address.GetAddress() == "" {
+			continue
+		}
+		cidr, err := inet.NewCidrAddressFromString(address.GetAddress())
+		if err != nil {
+			return nil, err
+		}
+		overlays = append(overlays, cidr)
+	}
+	var networks []net.IPNet
+	for _, peer := range rsp.GetPeers() {
+		for _, allowed := range peer.GetAllowedIps() {
+			cidr, err := inet.NewCidrAddressFromString(allowed.GetAddress())
+			if err != nil {
+				return nil, err
+			}
+			network := inet.NewNetworkAddress(cidr.GetNetwork())
+			if _, ok := network.LookupSupernet(overlays); ok {
+				continue
+			}
+			networks = append(networks, network.GetNetwork())
+		}
+	}
+	return inet.NewSubnetAddresses(inet.SummarizeNetworks(networks)...).GetNetworks(), nil
+}
+
+// networkStringers 将网络地址转换为日志中输出的格式
+func networkStringers(networks []net.IPNet) []*net.IPNet {
+	result := make([]*net.IPNet, 0, len(networks))
+	for i := range networks {
+		result = append(result, &networks[i])
+	}
+	return result
+}
@@ -0,0 +1,269 @@
+package client_test
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/onesaltedseafish/go-utils/log"
+	"github.com/onesaltedseafish/wg-tool/client"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"google.golang.org/grpc"
+)
+
+var (
+	logOpt = log.CommonLogOpt.WithDirectory("logs").WithLogLevel(zapcore.DebugLevel).WithTraceIDEnable(false).WithConsoleLog(false)
+	logger = log.GetLogger("client-test", &logOpt)
+	ctx    = context.Background()
+)
+
+// fakeRpc 返回固定的节点配置
+type fakeRpc struct {
+	pb.WireguardToolClient
+	config   *pb.PeerConfigRsp
+	switched []string      // 节点切换到的中继节点
+	lease    time.Duration // 地址的租约时长
+	now      time.Time     // 服务端续租时的时间
+	renewed  int           // 续租的次数
+}
+
+func (r *fakeRpc) GetPeerConfig(_ context.Context, req *pb.GetPeerConfigReq, _ ...grpc.CallOption) (*pb.PeerConfigRsp, error) {
+	return r.config, nil
+}
+
+// RenewLease 租约时长为 lease 时返回续租之后的到期时间，lease 为 0 时租约不会过期
+func (r *fakeRpc) RenewLease(_ context.Context, req *pb.RenewLeaseReq, _ ...grpc.CallOption) (*pb.RenewLeaseRsp, error) {
+	r.renewed++
+	if r.lease == 0 {
+		return &pb.RenewLeaseRsp{}, nil
+	}
+	return &pb.RenewLeaseRsp{ExpireTime: r.now.Add(r.lease).Unix()}, nil
+}
+
+// SwitchRelay 将中继节点的 AllowedIPs 移动到切换到的中继节点上
+func (r *fakeRpc) SwitchRelay(_ context.Context, req *pb.SwitchRelayReq, _ ...grpc.CallOption) (*pb.PeerConfigRsp, error) {
+	r.switched = append(r.switched, req.RelayName)
+	var allowedIps []*pb.CidrAddress
+	for _, peer := range r.config.Peers {
+		if peer.IsRelay && len(peer.AllowedIps) > 0 {
+			allowedIps, peer.AllowedIps = peer.AllowedIps, nil
+		}
+	}
+	for _, peer := range r.config.Peers {
+		if peer.PeerName == req.RelayName {
+			peer.AllowedIps = allowedIps
+		}
+	}
+	return r.config, nil
+}
+
+// fakeOperator 记录 wg 设备上的节点以及添加的路由，不真正修改内核
+type fakeOperator struct {
+	handshakes map[wgtypes.Key]time.Time   // 节点最后一次握手的时间
+	allowedIps map[wgtypes.Key][]net.IPNet // wg 设备上节点的 AllowedIPs
+	routes     map[string]net.IPNet        // map[network] 经过 wg 接口的路由
+}
+
+func newFakeOperator() *fakeOperator {
+	return &fakeOperator{
+		handshakes: make(map[wgtypes.Key]time.Time),
+		allowedIps: make(map[wgtypes.Key][]net.IPNet),
+		routes:     make(map[string]net.IPNet),
+	}
+}
+
+func (o *fakeOperator) GetPeers(interfaceName string) ([]wgtypes.Peer, error) {
+	var peers []wgtypes.Peer
+	for key, handshake := range o.handshakes {
+		peers = append(peers, wgtypes.Peer{PublicKey: key, LastHandshakeTime: handshake, AllowedIPs: o.allowedIps[key]})
+	}
+	return peers, nil
+}
+
+func (o *fakeOperator) ConfigurePeers(interfaceName string, peers []wgtypes.PeerConfig) error {
+	for _, peer := range peers {
+		o.allowedIps[peer.PublicKey] = peer.AllowedIPs
+	}
+	return nil
+}
+
+func (o *fakeOperator) AddRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	for _, network := range networks {
+		o.routes[network.String()] = network
+	}
+	return nil
+}
+
+func (o *fakeOperator) DelRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	for _, network := range networks {
+		delete(o.routes, network.String())
+	}
+	return nil
+}
+
+func (o *fakeOperator) GetRoutes(config wg.RouteConfig) ([]net.IPNet, error) {
+	return lo.Values(o.routes), nil
+}
+
+// routesString 返回排序后的路由，方便比较
+func (o *fakeOperator) routesString() []string {
+	routes := lo.Keys(o.routes)
+	slices.Sort(routes)
+	return routes
+}
+
+// toPbAddresses 将字符串转换为 pb 中的地址
+func toPbAddresses(addrs ...string) []*pb.CidrAddress {
+	return lo.Map(addrs, func(item string, _ int) *pb.CidrAddress {
+		return &pb.CidrAddress{Address: item}
+	})
+}
+
+func TestPeerRoutes(t *testing.T) {
+	config := &pb.PeerConfigRsp{
+		Address:  &pb.CidrAddress{Address: "192.168.222.2/24"},
+		Address6: &pb.CidrAddress{Address: "fd00:222::2/64"},
+		Peers: []*pb.RemotePeer{
+			{PeerName: "relay", IsRelay: true, AllowedIps: toPbAddresses("192.168.222.0/24", "fd00:222::/64", "10.1.0.0/24", "10.1.1.0/24")},
+			{PeerName: "relay2", IsRelay: true},
+			{PeerName: "site2", AllowedIps: toPbAddresses("192.168.222.3/32", "10.2.0.0/16", "fd00:1::/48")},
+		},
+	}
+	routes, err := client.PeerRoutes(config)
+	assert.Equal(t, nil, err)
+	// 覆盖网络中的地址不需要路由，相邻的子网合并为一条路由
+	assert.Equal(t, []string{"10.1.0.0/23", "10.2.0.0/16", "fd00:1::/48"}, lo.Map(routes, func(item net.IPNet, _ int) string {
+		return item.String()
+	}))
+
+	config.Peers[0].AllowedIps = toPbAddresses("10.1.0.0/33")
+	_, err = client.PeerRoutes(config)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSyncRoutes(t *testing.T) {
+	operator := newFakeOperator()
+	rpc := &fakeRpc{config: &pb.PeerConfigRsp{
+		Address: &pb.CidrAddress{Address: "192.168.222.2/24"},
+		Peers: []*pb.RemotePeer{
+			{PeerName: "relay", IsRelay: true, AllowedIps: toPbAddresses("192.168.222.0/24", "10.1.0.0/24", "10.2.0.0/24")},
+		},
+	}}
+	c := client.NewClient(rpc, logger, "node1", "wg0").WithOperator(operator).WithRouteConfig(100, 10)
+
+	_, err := c.Sync(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.2.0.0/24"}, operator.routesString())
+
+	// 其他节点的子网变化之后删除多余的路由
+	rpc.config.Peers[0].AllowedIps = toPbAddresses("192.168.222.0/24", "10.1.0.0/24", "10.3.0.0/24")
+	_, err = c.Sync(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.3.0.0/24"}, operator.routesString())
+
+	// 已经一致时不需要修改
+	diff, err := c.SyncRoutes(ctx, rpc.config)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, diff.Empty())
+}
+
+func TestCheckRelay(t *testing.T) {
+	_, relay1, _ := wg.GenerateWgKeyPairs()
+	_, relay2, _ := wg.GenerateWgKeyPairs()
+	now := time.Now()
+	operator := newFakeOperator()
+	operator.handshakes[relay1] = now.Add(-time.Minute)
+	operator.handshakes[relay2] = now.Add(-time.Minute)
+	rpc := &fakeRpc{config: &pb.PeerConfigRsp{
+		Address: &pb.CidrAddress{Address: "192.168.222.2/24"},
+		Peers: []*pb.RemotePeer{
+			{PeerName: "relay1", Pubkey: relay1.String(), IsRelay: true, AllowedIps: toPbAddresses("192.168.222.0/24", "10.1.0.0/24")},
+			{PeerName: "relay2", Pubkey: relay2.String(), IsRelay: true},
+		},
+	}}
+
+	// 没有开启故障切换时不检查
+	c := client.NewClient(rpc, logger, "node1", "wg0").WithOperator(operator)
+	_, err := c.Sync(ctx)
+	assert.Equal(t, nil, err)
+	operator.handshakes[relay1] = now.Add(-10 * time.Minute)
+	switched, err := c.CheckRelay(ctx, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, switched)
+
+	c.WithFailover(3 * time.Minute)
+	_, err = c.Sync(ctx)
+	assert.Equal(t, nil, err)
+	// 当前的中继节点没有握手，切换到 relay2 并通知服务端
+	switched, err = c.CheckRelay(ctx, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, switched)
+	assert.Equal(t, []string{"relay2"}, rpc.switched)
+	assert.Equal(t, 0, len(operator.allowedIps[relay1]))
+	assert.Equal(t, []string{"192.168.222.0/24", "10.1.0.0/24"}, lo.Map(operator.allowedIps[relay2], func(item net.IPNet, _ int) string {
+		return item.String()
+	}))
+	assert.Equal(t, []string{"10.1.0.0/24"}, operator.routesString())
+
+	// 切换之后以服务端返回的 relay2 作为当前的中继节点
+	switched, err = c.CheckRelay(ctx, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, switched)
+
+	// relay1 恢复之后 relay2 正常时不切换回去
+	operator.handshakes[relay1] = now
+	switched, err = c.CheckRelay(ctx, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, switched)
+
+	// relay2 没有握手时切换回 relay1
+	operator.handshakes[relay2] = now.Add(-10 * time.Minute)
+	switched, err = c.CheckRelay(ctx, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, switched)
+	assert.Equal(t, []string{"relay2", "relay1"}, rpc.switched)
+}
+
+func TestRenewLease(t *testing.T) {
+	now := time.Unix(time.Now().Unix(), 0)
+	rpc := &fakeRpc{config: &pb.PeerConfigRsp{}, lease: 10 * time.Minute, now: now}
+	c := client.NewClient(rpc, logger, "node1", "wg0").WithOperator(newFakeOperator())
+
+	// 第一次检查时续租，到期时间延长到 lease 之后
+	expireTime, err := c.RenewLease(ctx, now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, now.Add(10*time.Minute), expireTime)
+	assert.Equal(t, 1, rpc.renewed)
+
+	// 剩余租约时长的一半之前不续租
+	expireTime, err = c.RenewLease(ctx, now.Add(4*time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, expireTime.IsZero())
+	assert.Equal(t, 1, rpc.renewed)
+
+	// 剩余一半时续租，到期时间继续延长
+	rpc.now = now.Add(5 * time.Minute)
+	expireTime, err = c.RenewLease(ctx, now.Add(5*time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, now.Add(15*time.Minute), expireTime)
+	assert.Equal(t, 2, rpc.renewed)
+
+	// 租约不会过期时间隔一分钟重新续租
+	rpc.lease = 0
+	expireTime, err = c.RenewLease(ctx, now.Add(10*time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, expireTime.IsZero())
+	_, err = c.RenewLease(ctx, now.Add(10*time.Minute+30*time.Second))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, rpc.renewed)
+	_, err = c.RenewLease(ctx, now.Add(11*time.Minute))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, rpc.renewed)
+}
@@ -0,0 +1,34 @@
+// Package main 离线检查数据库中节点的子网冲突
+// 读取与服务端相同的配置文件，输出与覆盖网络、禁止使用的网络或者其他节点的子网重叠的子网
+// 存在冲突时以状态码 1 退出
+package main
+
+import (
+	"fmt"
+	"os"
+
+	config "github.com/onesaltedseafish/wg-tool"
+	"github.com/onesaltedseafish/wg-tool/models"
+)
+
+func main() {
+	config.InitConfig()
+	db, err := models.InitDb(models.InitSqlite(config.Config.SqlitePath), false, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "open db failed:", err)
+		os.Exit(2)
+	}
+	conflicts, err := models.FindSubnetConflicts(db, config.Config.Subnet.DeniedNetworks())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "find subnet conflicts failed:", err)
+		os.Exit(2)
+	}
+	for _, conflict := range conflicts {
+		fmt.Println(conflict.String())
+	}
+	if len(conflicts) > 0 {
+		fmt.Printf("found %d subnet conflicts\n", len(conflicts))
+		os.Exit(1)
+	}
+	fmt.Println("no subnet conflicts")
+}
@@ -1,6 +1,126 @@
 // Package main 客户端侧
+// 节点的 wg 接口需要已经按照注册时返回的配置启动，客户端定期从服务端获取节点的配置并同步经过 wg 接口的路由
+// 注册了多个中继节点时，客户端定期检查当前中继节点的握手情况，超时时切换到其他中继节点
+// 服务端开启地址租约时，客户端在租约到期之前续租
 package main
 
-func main(){
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-}
\ No newline at end of file
+	"github.com/onesaltedseafish/go-utils/log"
+	"github.com/onesaltedseafish/wg-tool/client"
+	"github.com/onesaltedseafish/wg-tool/commons/auth"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	server        = flag.String("server", "127.0.0.1:50051", "服务端的 gRPC 地址")
+	token         = flag.String("token", "", "访问服务端使用的 token")
+	tlsCA         = flag.String("tls_ca", "", "校验服务端证书的 CA，为空时使用系统的 CA")
+	tlsCert       = flag.String("tls_cert", "", "双向认证时客户端的证书")
+	tlsKey        = flag.String("tls_key", "", "双向认证时客户端的私钥")
+	serverName    = flag.String("server_name", "", "校验服务端证书使用的域名，为空时使用服务端地址")
+	insecureMode  = flag.Bool("insecure", false, "不使用 TLS 连接服务端，token 会明文传输")
+	peerName      = flag.String("name", "", "节点名")
+	interfaceName = flag.String("interface", "wg0", "wg 接口名")
+	routeTable    = flag.Int("route_table", 0, "路由表 ID，为 0 时使用 main 表")
+	routeMetric   = flag.Int("route_metric", 0, "路由的优先级")
+	interval      = flag.Duration("interval", time.Minute, "同步配置的间隔")
+	failover      = flag.Duration("failover_timeout", 3*time.Minute, "中继节点的握手超时时长，为 0 时不切换中继节点")
+	checkInterval = flag.Duration("check_interval", 10*time.Second, "检查中继节点握手情况以及续租的间隔，需要小于租约时长的一半")
+	logLevel      = flag.String("log_level", "info", "日志级别")
+	logDirectory  = flag.String("log_dir", "./logs", "日志目录")
+)
+
+var (
+	logger *log.Logger
+	ctx    = context.Background()
+)
+
+func main() {
+	flag.Parse()
+	level, err := zapcore.ParseLevel(*logLevel)
+	if err != nil {
+		level = zapcore.InfoLevel
+	}
+	logOpt := log.CommonLogOpt.WithDirectory(*logDirectory).WithLogLevel(level)
+	logger = log.GetLogger("wg-tool-client", &logOpt)
+	if *peerName == "" {
+		logger.Fatal(ctx, "peer name is required")
+	}
+
+	conn, err := grpc.NewClient(*server, dialOptions()...)
+	if err != nil {
+		logger.Fatal(ctx, "dial server failed", zap.String("server", *server), zap.Error(err))
+	}
+	defer conn.Close()
+	c := client.NewClient(pb.NewWireguardToolClient(conn), logger, *peerName, *interfaceName).
+		WithRouteConfig(*routeTable, *routeMetric).
+		WithFailover(*failover)
+	logger.Info(ctx, "start wg-tool client", zap.String("server", *server), zap.String("peer", *peerName),
+		zap.String("interface", *interfaceName))
+	run(c)
+}
+
+// dialOptions 根据参数决定是否使用 TLS 以及双向认证
+func dialOptions() []grpc.DialOption {
+	if *insecureMode {
+		logger.Warn(ctx, "tls is disabled, token will be transferred in plaintext")
+		return []grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			auth.WithInsecureToken(*token),
+		}
+	}
+	tlsConfig, err := auth.ClientTLSConfig(*tlsCA, *tlsCert, *tlsKey, *serverName)
+	if err != nil {
+		logger.Fatal(ctx, "load tls config failed", zap.Error(err))
+	}
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		auth.WithToken(*token),
+	}
+}
+
+// run 定期同步节点的配置、检查中继节点以及续租，收到退出信号后退出
+func run(c *client.Client) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	checker := time.NewTicker(*checkInterval)
+	defer checker.Stop()
+	if _, err := c.Sync(ctx); err != nil {
+		logger.Error(ctx, "sync peer config failed", zap.Error(err))
+	}
+	if _, err := c.RenewLease(ctx, time.Now()); err != nil {
+		logger.Error(ctx, "renew lease failed", zap.Error(err))
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, "stop wg-tool client")
+			return
+		case <-ticker.C:
+			if _, err := c.Sync(ctx); err != nil {
+				logger.Error(ctx, "sync peer config failed", zap.Error(err))
+			}
+		case now := <-checker.C:
+			if _, err := c.CheckRelay(ctx, now); err != nil {
+				logger.Error(ctx, "check relay failed", zap.Error(err))
+			}
+			if _, err := c.RenewLease(ctx, now); err != nil {
+				logger.Error(ctx, "renew lease failed", zap.Error(err))
+			}
+		}
+	}
+}
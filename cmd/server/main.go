@@ -3,13 +3,24 @@ package main
 
 import (
 	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/onesaltedseafish/go-utils/log"
 	gormLog "github.com/onesaltedseafish/go-utils/log/gorm"
 	config "github.com/onesaltedseafish/wg-tool"
+	"github.com/onesaltedseafish/wg-tool/commons/auth"
 	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/onesaltedseafish/wg-tool/services"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"gorm.io/gorm"
 )
 
 var (
@@ -26,16 +37,118 @@ func initLog() {
 }
 
 // 初始化 DB
-func initDb() {
+func initDb() *gorm.DB {
 	dialector := models.InitSqlite(config.Config.SqlitePath)
-	if _, err := models.InitDb(dialector, true, gormLogger); err != nil {
+	db, err := models.InitDb(dialector, true, gormLogger)
+	if err != nil {
 		logger.Fatal(ctx, "init db failed", zap.Error(err))
 	}
+	return db
+}
+
+// 将配置文件中的网络配置转换为中继节点的配置
+func networkConfig() services.NetworkConfig {
+	network := config.Config.Network
+	return services.NetworkConfig{
+		PeerName:          network.PeerName,
+		InterfaceName:     network.InterfaceName,
+		Address:           network.Cidr,
+		Address6:          network.Cidr6,
+		ListenPort:        network.ListenPort,
+		PublicIp:          network.PublicIp.String(),
+		KeepAliveInterval: network.KeepAlive,
+	}
+}
+
+// 根据配置决定是否开启 TLS 以及双向认证
+func serverCredentials() credentials.TransportCredentials {
+	if config.Config.TlsCert == "" {
+		logger.Warn(ctx, "tls is disabled, keys will be transferred in plaintext")
+		return insecure.NewCredentials()
+	}
+	tlsConfig, err := auth.ServerTLSConfig(config.Config.TlsCert, config.Config.TlsKey, config.Config.TlsClientCA)
+	if err != nil {
+		logger.Fatal(ctx, "load tls config failed", zap.Error(err))
+	}
+	return credentials.NewTLS(tlsConfig)
+}
+
+// 启动 gRPC 服务，收到退出信号后优雅退出
+func serve(db *gorm.DB) {
+	lis, err := net.Listen("tcp", config.Config.Listen)
+	if err != nil {
+		logger.Fatal(ctx, "listen failed", zap.String("listen", config.Config.Listen), zap.Error(err))
+	}
+	grpcServer := grpc.NewServer(
+		grpc.Creds(serverCredentials()),
+		grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(config.Config.Token)),
+		grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(config.Config.Token)),
+	)
+	topology, err := models.ParseTopology(config.Config.Network.Topology)
+	if err != nil {
+		logger.Fatal(ctx, "config network topology invalid", zap.Error(err))
+	}
+	strategy, err := models.ParseAllocateStrategy(config.Config.Network.Allocator)
+	if err != nil {
+		logger.Fatal(ctx, "config network allocator invalid", zap.Error(err))
+	}
+	strategy6, err := models.ParseAllocateStrategy(config.Config.Network.Allocator6)
+	if err != nil {
+		logger.Fatal(ctx, "config network allocator6 invalid", zap.Error(err))
+	}
+	pool, err := models.ParsePoolConfig(config.Config.Network.Pool.Start, config.Config.Network.Pool.End,
+		config.Config.Network.Pool.Exclude)
+	if err != nil {
+		logger.Fatal(ctx, "config network pool invalid", zap.Error(err))
+	}
+	pool6, err := models.ParsePoolConfig(config.Config.Network.Pool6.Start, config.Config.Network.Pool6.End,
+		config.Config.Network.Pool6.Exclude)
+	if err != nil {
+		logger.Fatal(ctx, "config network pool6 invalid", zap.Error(err))
+	}
+	server := services.NewServer(db, logger).
+		WithServerKeygen(!config.Config.DisableKeygen).
+		WithPresharedKeyRequired(config.Config.RequirePsk).
+		WithRouteConfig(config.Config.Route.Table, config.Config.Route.Metric).
+		WithTopology(topology).
+		WithAllocateStrategy(strategy, strategy6).
+		WithAddressPool(pool, pool6).
+		WithSubnetPool(config.Config.Subnet.Supernet.GetNetwork(), config.Config.Subnet.Prefix).
+		WithDeniedSubnets(config.Config.Subnet.DeniedNetworks()).
+		WithLease(config.Config.Lease.Duration, config.Config.Lease.Duration6)
+	pb.RegisterWireguardToolServer(grpcServer, server)
+
+	// 启动中继节点
+	if err = server.Bootstrap(ctx, networkConfig()); err != nil {
+		logger.Fatal(ctx, "bootstrap relay failed", zap.Error(err))
+	}
+
+	// 同步数据库与 wg 设备
+	reconcileCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go server.RunReconciler(reconcileCtx, config.Config.Reconcile.Interval, config.Config.Reconcile.DryRun)
+	// 回收到期的租约
+	go server.RunLeaseReaper(reconcileCtx, config.Config.Lease.ReapInterval)
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		s := <-sig
+		logger.Info(ctx, "receive signal, stop wg-tool server", zap.String("signal", s.String()))
+		cancel()
+		grpcServer.GracefulStop()
+	}()
+
+	logger.Info(ctx, "wg-tool server listening", zap.String("listen", lis.Addr().String()))
+	if err = grpcServer.Serve(lis); err != nil {
+		logger.Fatal(ctx, "serve failed", zap.Error(err))
+	}
 }
 
 func main() {
 	config.InitConfig()
 	initLog()
-	initDb()
+	db := initDb()
 	logger.Info(ctx, "start wg-tool server", zap.Any("config", config.Config))
+	serve(db)
 }
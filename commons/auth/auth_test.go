@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testToken = "test-token"
+
+// testServerStream 只用于传递 context
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// newIncomingContext 模拟客户端使用 TokenCredentials 发起的请求
+func newIncomingContext(t *testing.T, token string) context.Context {
+	md, err := NewTokenCredentials(token).GetRequestMetadata(context.Background())
+	assert.Equal(t, nil, err)
+	return metadata.NewIncomingContext(context.Background(), metadata.New(md))
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := UnaryServerInterceptor(testToken)
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+	testcases := []struct {
+		Ctx  context.Context
+		Want codes.Code
+	}{
+		{newIncomingContext(t, testToken), codes.OK},
+		{newIncomingContext(t, "wrong-token"), codes.Unauthenticated},
+		{newIncomingContext(t, ""), codes.Unauthenticated},
+		{context.Background(), codes.Unauthenticated},                                                                          // 没有 metadata
+		{metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", testToken)), codes.Unauthenticated}, // 缺少 Bearer 前缀
+	}
+	for _, testcase := range testcases {
+		_, err := interceptor(testcase.Ctx, nil, &grpc.UnaryServerInfo{}, handler)
+		assert.Equal(t, testcase.Want, status.Code(err))
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	interceptor := StreamServerInterceptor(testToken)
+	handler := func(srv any, stream grpc.ServerStream) error {
+		return nil
+	}
+	err := interceptor(nil, testServerStream{ctx: newIncomingContext(t, testToken)}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.OK, status.Code(err))
+	err = interceptor(nil, testServerStream{ctx: newIncomingContext(t, "wrong-token")}, &grpc.StreamServerInfo{}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestTransportSecurity(t *testing.T) {
+	assert.Equal(t, true, NewTokenCredentials(testToken).RequireTransportSecurity())
+	assert.Equal(t, false, NewInsecureTokenCredentials(testToken).RequireTransportSecurity())
+
+	// 没有 TLS 的连接不能使用默认的 token 认证
+	_, err := grpc.NewClient("127.0.0.1:50051", grpc.WithTransportCredentials(insecure.NewCredentials()), WithToken(testToken))
+	assert.NotEqual(t, nil, err)
+	conn, err := grpc.NewClient("127.0.0.1:50051", grpc.WithTransportCredentials(insecure.NewCredentials()), WithInsecureToken(testToken))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, conn.Close())
+}
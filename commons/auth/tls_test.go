@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert 测试使用的证书
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certFile string
+	keyFile  string
+}
+
+// newTestCert 生成证书，parent 为空时生成自签名的 CA 证书
+func newTestCert(t *testing.T, dir, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Equal(t, nil, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signerCert, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	assert.Equal(t, nil, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Equal(t, nil, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Equal(t, nil, err)
+
+	result := &testCert{
+		cert:     cert,
+		key:      key,
+		certFile: filepath.Join(dir, name+".crt"),
+		keyFile:  filepath.Join(dir, name+".key"),
+	}
+	assert.Equal(t, nil, os.WriteFile(result.certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.Equal(t, nil, os.WriteFile(result.keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return result
+}
+
+// handshake 使用给定的配置进行一次 TLS 握手
+func handshake(serverConfig, clientConfig *tls.Config) error {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- tls.Server(serverConn, serverConfig).Handshake()
+	}()
+	clientErr := tls.Client(clientConn, clientConfig).Handshake()
+	clientConn.Close()
+	serverErr := <-errCh
+	if clientErr != nil {
+		return clientErr
+	}
+	return serverErr
+}
+
+func TestTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, dir, "ca", nil)
+	server := newTestCert(t, dir, "server.wg-tool", ca)
+	client := newTestCert(t, dir, "client.wg-tool", ca)
+	otherCa := newTestCert(t, dir, "other-ca", nil)
+
+	// 单向认证
+	serverConfig, err := ServerTLSConfig(server.certFile, server.keyFile, "")
+	assert.Equal(t, nil, err)
+	clientConfig, err := ClientTLSConfig(ca.certFile, "", "", "server.wg-tool")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, handshake(serverConfig, clientConfig))
+
+	// 服务端证书不是由信任的 CA 签发
+	clientConfig, err = ClientTLSConfig(otherCa.certFile, "", "", "server.wg-tool")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, handshake(serverConfig, clientConfig))
+
+	// 双向认证
+	serverConfig, err = ServerTLSConfig(server.certFile, server.keyFile, ca.certFile)
+	assert.Equal(t, nil, err)
+	clientConfig, err = ClientTLSConfig(ca.certFile, client.certFile, client.keyFile, "server.wg-tool")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, handshake(serverConfig, clientConfig))
+
+	// 双向认证时客户端没有出示证书
+	clientConfig, err = ClientTLSConfig(ca.certFile, "", "", "server.wg-tool")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, handshake(serverConfig, clientConfig))
+
+	// 非法的 CA 文件
+	_, err = ServerTLSConfig(server.certFile, server.keyFile, server.keyFile)
+	assert.NotEqual(t, nil, err)
+}
@@ -4,7 +4,33 @@ package errs
 import "errors"
 
 var (
-	WgNoConnectPeerError = errors.New("Wg没有连接的节点")
-	WgInvalidPortError = errors.New("Wg端口非法")
-	WgInvalidAddressError = errors.New("Wg地址非法")
+	WgNoConnectPeerError       = errors.New("Wg没有连接的节点")
+	WgInvalidPortError         = errors.New("Wg端口非法")
+	WgInvalidAddressError      = errors.New("Wg地址非法")
+	WgNoRelayPeerError         = errors.New("Wg没有可用的中继节点")
+	WgPeerExistsError          = errors.New("Wg节点已经存在")
+	WgInvalidPeerNameError     = errors.New("Wg节点名非法")
+	WgInvalidPeerTypeError     = errors.New("Wg节点类型非法")
+	WgPeerNotFoundError        = errors.New("Wg节点不存在")
+	WgRelayPeerError           = errors.New("Wg不能操作中继节点")
+	WgInvalidKeepAliveError    = errors.New("Wg保活时长非法")
+	WgInvalidKeyError          = errors.New("Wg密钥非法")
+	WgKeygenDisabledError      = errors.New("Wg服务端不允许生成密钥")
+	WgNetworkChangedError      = errors.New("Wg网络地址与已有的中继节点不一致")
+	WgInvalidTopologyError     = errors.New("Wg网络拓扑非法")
+	WgInvalidRelayError        = errors.New("Wg节点没有配置该中继节点")
+	WgInvalidStrategyError     = errors.New("Wg地址分配策略非法")
+	WgLeaseNotFoundError       = errors.New("Wg地址没有有效的租约")
+	WgReservationExistsError   = errors.New("Wg地址已经被预留")
+	WgReservationNotFoundError = errors.New("Wg预留地址不存在")
+	WgInvalidReservationError  = errors.New("Wg预留地址需要指定节点")
+	WgAddressConflictError     = errors.New("Wg地址已经被其他节点占用")
+	WgPoolExhaustedError       = errors.New("Wg地址池中没有可以分配的地址")
+	WgInvalidPoolError         = errors.New("Wg地址池配置非法")
+	WgNoSupernetError          = errors.New("Wg没有配置分配子网的超网")
+	WgInvalidPrefixError       = errors.New("Wg子网前缀长度非法")
+	WgSubnetExhaustedError     = errors.New("Wg超网中没有可以分配的子网")
+	WgSubnetConflictError      = errors.New("Wg子网与其他网络重叠")
+	InvalidPageTokenError      = errors.New("分页token非法")
+	TlsInvalidCAError          = errors.New("CA证书中没有可用的证书")
 )
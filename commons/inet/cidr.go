@@ -0,0 +1,272 @@
+package inet
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+)
+
+// MaxSubnets Subnets 一次最多返回的子网数量
+const MaxSubnets = 1 << 16
+
+// NewNetworkAddress 使用网络地址初始化 CIDR 地址，主机地址为网络地址
+func NewNetworkAddress(network net.IPNet) CidrAddress {
+	return NewCidrAddress(network.IP.Mask(network.Mask), network)
+}
+
+// NextIP 返回下一个地址，溢出时返回 nil
+func NextIP(ip net.IP) net.IP {
+	return addIP(ip, 1)
+}
+
+// PrevIP 返回上一个地址，溢出时返回 nil
+func PrevIP(ip net.IP) net.IP {
+	return addIP(ip, -1)
+}
+
+// PrefixLen 网络的前缀长度以及地址的总位数
+func (addr CidrAddress) PrefixLen() (ones, bits int) {
+	return addr.network.Mask.Size()
+}
+
+// Contains 网络中是否包含该地址
+func (addr CidrAddress) Contains(ip net.IP) bool {
+	return addr.network.IP != nil && addr.network.Contains(ip)
+}
+
+// ContainsNetwork 网络是否完整包含另一个网络
+func (addr CidrAddress) ContainsNetwork(other CidrAddress) bool {
+	ones, bits := addr.PrefixLen()
+	otherOnes, otherBits := other.PrefixLen()
+	return bits == otherBits && ones <= otherOnes && addr.Contains(other.network.IP)
+}
+
+// Overlaps 两个网络是否重叠，不同协议的网络不会重叠
+func (addr CidrAddress) Overlaps(other CidrAddress) bool {
+	return addr.ContainsNetwork(other) || other.ContainsNetwork(addr)
+}
+
+// AddressCount 网络中所有地址的数量，包括网络地址以及广播地址
+func (addr CidrAddress) AddressCount() *big.Int {
+	ones, bits := addr.PrefixLen()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
+
+// HostCount 网络中可以分配给主机的地址数量，即 FirstUsable 到 LastUsable 之间的地址数量
+func (addr CidrAddress) HostCount() *big.Int {
+	if addr.network.IP == nil {
+		return new(big.Int)
+	}
+	first, last := ipToInt(addr.FirstUsable()), ipToInt(addr.LastUsable())
+	count := new(big.Int).Sub(last, first)
+	return count.Add(count, big.NewInt(1))
+}
+
+// FirstUsable 网络中第一个可以分配给主机的地址，跳过网络地址
+// IPv4 的 /31、/32 以及 IPv6 的 /127、/128 是点对点或者单个主机的网络，所有地址都可以使用
+func (addr CidrAddress) FirstUsable() net.IP {
+	first := normalizeIP(addr.network.IP.Mask(addr.network.Mask))
+	if addr.isPointToPoint() {
+		return first
+	}
+	return NextIP(first)
+}
+
+// LastUsable 网络中最后一个可以分配给主机的地址，IPv4 跳过广播地址
+func (addr CidrAddress) LastUsable() net.IP {
+	last := addr.LastAddress()
+	if broadcast := addr.Broadcast(); broadcast != nil {
+		return PrevIP(broadcast)
+	}
+	return last
+}
+
+// LastAddress 网络中最后一个地址，主机位全部为 1
+func (addr CidrAddress) LastAddress() net.IP {
+	network := normalizeIP(addr.network.IP.Mask(addr.network.Mask))
+	if network == nil {
+		return nil
+	}
+	mask := addr.network.Mask
+	last := make(net.IP, len(network))
+	for i := range last {
+		last[i] = network[i] | ^mask[len(mask)-len(network)+i]
+	}
+	return last
+}
+
+// Broadcast IPv4 网络的广播地址，IPv6 以及 /31、/32 的网络没有广播地址，返回 nil
+func (addr CidrAddress) Broadcast() net.IP {
+	if !addr.isIPv4Network() || addr.isPointToPoint() {
+		return nil
+	}
+	return addr.LastAddress()
+}
+
+// Next 网络中的下一个地址，超出网络时返回 false
+func (addr CidrAddress) Next() (CidrAddress, bool) {
+	return addr.move(NextIP(addr.address))
+}
+
+// Prev 网络中的上一个地址，超出网络时返回 false
+func (addr CidrAddress) Prev() (CidrAddress, bool) {
+	return addr.move(PrevIP(addr.address))
+}
+
+// Subnets 将网络划分为前缀长度为 prefix 的子网，子网数量不能超过 MaxSubnets
+func (addr CidrAddress) Subnets(prefix int) ([]CidrAddress, error) {
+	ones, bits := addr.PrefixLen()
+	if addr.network.IP == nil || prefix < ones || prefix > bits {
+		return nil, fmt.Errorf("%w: /%d 不能从 %s 中划分", errs.WgInvalidPrefixError, prefix, addr.network.String())
+	}
+	if prefix-ones > 16 {
+		return nil, fmt.Errorf("%w: %s 划分为 /%d 的子网数量超过 %d", errs.WgInvalidPrefixError, addr.network.String(), prefix, MaxSubnets)
+	}
+	mask := net.CIDRMask(prefix, bits)
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+	start := ipToInt(normalizeIP(addr.network.IP.Mask(addr.network.Mask)))
+	subnets := make([]CidrAddress, 0, 1<<(prefix-ones))
+	for i := 0; i < 1<<(prefix-ones); i++ {
+		n := new(big.Int).Mul(size, big.NewInt(int64(i)))
+		ip := intToIP(n.Add(n, start), len(mask))
+		subnets = append(subnets, NewNetworkAddress(net.IPNet{IP: ip, Mask: mask}))
+	}
+	return subnets, nil
+}
+
+// Supernet 包含该网络的前缀长度为 prefix 的网络
+func (addr CidrAddress) Supernet(prefix int) (CidrAddress, error) {
+	ones, bits := addr.PrefixLen()
+	if addr.network.IP == nil || prefix < 0 || prefix > ones {
+		return CidrAddress{}, fmt.Errorf("%w: %s 没有 /%d 的超网", errs.WgInvalidPrefixError, addr.network.String(), prefix)
+	}
+	mask := net.CIDRMask(prefix, bits)
+	return NewNetworkAddress(net.IPNet{IP: addr.network.IP.Mask(mask), Mask: mask}), nil
+}
+
+// LookupSupernet 在 networks 中查找包含该网络的前缀最长的网络
+func (addr CidrAddress) LookupSupernet(networks []CidrAddress) (CidrAddress, bool) {
+	var found CidrAddress
+	longest := -1
+	for _, network := range networks {
+		if ones, _ := network.PrefixLen(); network.ContainsNetwork(addr) && ones > longest {
+			found, longest = network, ones
+		}
+	}
+	return found, longest >= 0
+}
+
+// move 移动到网络中的另一个地址
+func (addr CidrAddress) move(ip net.IP) (CidrAddress, bool) {
+	if ip == nil || !addr.Contains(ip) {
+		return CidrAddress{}, false
+	}
+	return CidrAddress{address: ip, network: addr.network}, true
+}
+
+// isIPv4Network 是否为 IPv4 网络
+func (addr CidrAddress) isIPv4Network() bool {
+	_, bits := addr.PrefixLen()
+	return bits == 8*net.IPv4len
+}
+
+// isPointToPoint 是否为点对点或者单个主机的网络，即 /31、/32、/127 以及 /128
+func (addr CidrAddress) isPointToPoint() bool {
+	ones, bits := addr.PrefixLen()
+	return bits-ones <= 1
+}
+
+// addIP 地址加上 delta，溢出时返回 nil
+func addIP(ip net.IP, delta int64) net.IP {
+	ip = normalizeIP(ip)
+	if ip == nil {
+		return nil
+	}
+	n := new(big.Int).Add(ipToInt(ip), big.NewInt(delta))
+	if n.Sign() < 0 || n.BitLen() > 8*len(ip) {
+		return nil
+	}
+	return intToIP(n, len(ip))
+}
+
+// normalizeIP IPv4 地址使用 4 字节表示
+func normalizeIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
+
+func ipToInt(ip net.IP) *big.Int {
+	return new(big.Int).SetBytes(normalizeIP(ip))
+}
+
+func intToIP(n *big.Int, size int) net.IP {
+	return n.FillBytes(make(net.IP, size))
+}
+
+// Normalize 规范化子网地址，清除主机位、去掉重复以及被包含的子网，并合并相邻的子网
+// 返回覆盖同样地址范围的最少的子网，IPv4 在 IPv6 之前，同一协议按地址排序
+func (subnet SubnetAddresses) Normalize() SubnetAddresses {
+	return NewSubnetAddresses(SummarizeNetworks(subnet.GetNetworks())...)
+}
+
+// SummarizeNetworks 将多个网络汇总为覆盖同样地址范围的最少的网络
+func SummarizeNetworks(networks []net.IPNet) []CidrAddress {
+	type prefix struct {
+		start *big.Int
+		ones  int
+		bits  int
+	}
+	prefixes := make([]prefix, 0, len(networks))
+	for _, network := range networks {
+		if network.IP == nil {
+			continue
+		}
+		ones, bits := network.Mask.Size()
+		start := ipToInt(network.IP.Mask(network.Mask))
+		prefixes = append(prefixes, prefix{start: start, ones: ones, bits: bits})
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		a, b := prefixes[i], prefixes[j]
+		if a.bits != b.bits {
+			return a.bits < b.bits
+		}
+		if c := a.start.Cmp(b.start); c != 0 {
+			return c < 0
+		}
+		return a.ones < b.ones
+	})
+	// 前缀的结束地址，不包含
+	end := func(p prefix) *big.Int {
+		return new(big.Int).Add(p.start, new(big.Int).Lsh(big.NewInt(1), uint(p.bits-p.ones)))
+	}
+
+	var merged []prefix
+	for _, p := range prefixes {
+		// 按开始地址排序之后，被包含的前缀总是紧跟在包含它的前缀之后
+		if n := len(merged); n > 0 && merged[n-1].bits == p.bits && end(p).Cmp(end(merged[n-1])) <= 0 {
+			continue
+		}
+		merged = append(merged, p)
+		// 与前一个前缀组成对齐的兄弟前缀时合并为上一级前缀，合并之后可能继续与前一个前缀合并
+		for n := len(merged); n > 1; n = len(merged) {
+			a, b := merged[n-2], merged[n-1]
+			if a.bits != b.bits || a.ones != b.ones || a.ones == 0 || end(a).Cmp(b.start) != 0 ||
+				new(big.Int).Rsh(a.start, uint(a.bits-a.ones)).Bit(0) != 0 {
+				break
+			}
+			merged = append(merged[:n-2], prefix{start: a.start, ones: a.ones - 1, bits: a.bits})
+		}
+	}
+
+	addrs := make([]CidrAddress, 0, len(merged))
+	for _, p := range merged {
+		mask := net.CIDRMask(p.ones, p.bits)
+		addrs = append(addrs, NewNetworkAddress(net.IPNet{IP: intToIP(p.start, len(mask)), Mask: mask}))
+	}
+	return addrs
+}
@@ -93,12 +93,45 @@ func (addr CidrAddress) GetNetwork() net.IPNet {
 	return addr.network
 }
 
+// GetAddress 获取主机地址
+func (addr CidrAddress) GetAddress() net.IP {
+	return addr.address
+}
+
+// GetHostNetwork 获取只包含主机地址的网络，即 /32 或者 /128
+func (addr CidrAddress) GetHostNetwork() net.IPNet {
+	_, bits := addr.network.Mask.Size()
+	return net.IPNet{
+		IP:   addr.address,
+		Mask: net.CIDRMask(bits, bits),
+	}
+}
+
+// IsZero 是否为空地址
+func (addr CidrAddress) IsZero() bool {
+	return addr.address == nil
+}
+
+// IsIPv4 是否为 IPv4 地址
+func (addr CidrAddress) IsIPv4() bool {
+	return addr.address.To4() != nil
+}
+
 // Scan 实现 sql.Scanner 接口，Scan 将 value 扫描至
+// 空字符串以及 NULL 扫描为空地址
 func (addr *CidrAddress) Scan(value any) (err error) {
+	if value == nil {
+		*addr = CidrAddress{}
+		return nil
+	}
 	v, ok := value.(string)
 	if !ok {
 		return fmt.Errorf("Fail to unmarshal cidr address value: %v", value)
 	}
+	if v == "" {
+		*addr = CidrAddress{}
+		return nil
+	}
 	a, n, e := net.ParseCIDR(v)
 	if e != nil {
 		return e
@@ -125,11 +158,27 @@ func NewCidrAddressFromString(addr string) (CidrAddress, error) {
 	}, nil
 }
 
+// NewCidrAddress 使用主机地址和所在的子网初始化CIDR地址
+func NewCidrAddress(address net.IP, network net.IPNet) CidrAddress {
+	return CidrAddress{
+		address: address,
+		network: net.IPNet{
+			IP:   address.Mask(network.Mask),
+			Mask: network.Mask,
+		},
+	}
+}
+
 // SubnetAddresses 定义子网地址
 type SubnetAddresses struct {
 	address []CidrAddress
 }
 
+// NewSubnetAddresses 使用多个子网地址初始化
+func NewSubnetAddresses(addrs ...CidrAddress) SubnetAddresses {
+	return SubnetAddresses{address: addrs}
+}
+
 // NewSubnetAddressesFromString 从字符串中初始化多个子网地址
 func NewSubnetAddressesFromString(addrs string) (SubnetAddresses, error) {
 	var err error
@@ -158,6 +207,11 @@ func (subnet SubnetAddresses) GetNetworks() []net.IPNet {
 	})
 }
 
+// GetAddresses get all CIDR addresses
+func (subnet SubnetAddresses) GetAddresses() []CidrAddress {
+	return subnet.address
+}
+
 func (subnet SubnetAddresses) String() string {
 	return strings.Join(lo.Map(subnet.address, func(item CidrAddress, _ int) string {
 		return item.String()
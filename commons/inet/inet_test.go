@@ -1,10 +1,15 @@
 package inet
 
 import (
+	"encoding/json"
+	"net"
 	"testing"
 
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/vishvananda/netlink"
+	"gopkg.in/yaml.v3"
 )
 
 func TestCidrAddress(t *testing.T) {
@@ -16,6 +21,7 @@ func TestCidrAddress(t *testing.T) {
 		{"192.168.23.23/23", "192.168.23.23/23"},
 		{"123.123.123.256/24", ""}, // 非法的CIDR，不返回任何值
 		{"123.123.123.123/32", "123.123.123.123/32"},
+		{"fd00:222::1/64", "fd00:222::1/64"},
 	}
 
 	for _, testcase := range testcases {
@@ -47,3 +53,314 @@ func TestParseIpAddressFromString(t *testing.T){
 		assert.Equal(t, testcase.Want, ParseIpAddressFromString(testcase.Ori).String())
 	}
 }
+
+func TestNewCidrAddress(t *testing.T) {
+	_, network, err := net.ParseCIDR("192.168.222.1/24")
+	assert.Equal(t, nil, err)
+	addr := NewCidrAddress(net.ParseIP("192.168.222.10"), *network)
+	assert.Equal(t, "192.168.222.10/24", addr.String())
+	network2 := addr.GetNetwork()
+	assert.Equal(t, "192.168.222.0/24", network2.String())
+	hostNet := addr.GetHostNetwork()
+	assert.Equal(t, "192.168.222.10/32", hostNet.String())
+}
+
+func TestCidrAddressIPv6(t *testing.T) {
+	addr, err := NewCidrAddressFromString("fd00:222::10/64")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, addr.IsIPv4())
+	network := addr.GetNetwork()
+	assert.Equal(t, "fd00:222::/64", network.String())
+	hostNet := addr.GetHostNetwork()
+	assert.Equal(t, "fd00:222::10/128", hostNet.String())
+
+	// 空字符串以及 NULL 都是空地址
+	for _, value := range []any{nil, ""} {
+		var scanned CidrAddress
+		assert.Equal(t, nil, scanned.Scan(value))
+		assert.Equal(t, true, scanned.IsZero())
+	}
+	assert.Equal(t, nil, addr.Scan("192.168.0.1/24"))
+	assert.Equal(t, true, addr.IsIPv4())
+}
+
+func TestCidrRange(t *testing.T) {
+	testcases := []struct {
+		Cidr      string
+		First     string
+		Last      string
+		Broadcast string
+		Hosts     string
+	}{
+		{"192.168.0.1/24", "192.168.0.1", "192.168.0.254", "192.168.0.255", "254"},
+		{"10.0.0.0/30", "10.0.0.1", "10.0.0.2", "10.0.0.3", "2"},
+		{"10.0.0.0/31", "10.0.0.0", "10.0.0.1", "<nil>", "2"},
+		{"10.0.0.7/32", "10.0.0.7", "10.0.0.7", "<nil>", "1"},
+		{"0.0.0.0/0", "0.0.0.1", "255.255.255.254", "255.255.255.255", "4294967294"},
+		{"fd00:222::1/64", "fd00:222::1", "fd00:222::ffff:ffff:ffff:ffff", "<nil>", "18446744073709551615"},
+		{"fd00::/126", "fd00::1", "fd00::3", "<nil>", "3"},
+		{"fd00::/127", "fd00::", "fd00::1", "<nil>", "2"},
+		{"fd00::5/128", "fd00::5", "fd00::5", "<nil>", "1"},
+	}
+	for _, testcase := range testcases {
+		addr, err := NewCidrAddressFromString(testcase.Cidr)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, testcase.First, addr.FirstUsable().String(), testcase.Cidr)
+		assert.Equal(t, testcase.Last, addr.LastUsable().String(), testcase.Cidr)
+		assert.Equal(t, testcase.Broadcast, addr.Broadcast().String(), testcase.Cidr)
+		assert.Equal(t, testcase.Hosts, addr.HostCount().String(), testcase.Cidr)
+	}
+	addr, _ := NewCidrAddressFromString("fd00::/0")
+	assert.Equal(t, "340282366920938463463374607431768211456", addr.AddressCount().String())
+	assert.Equal(t, "0", CidrAddress{}.HostCount().String())
+}
+
+func TestCidrContains(t *testing.T) {
+	testcases := []struct {
+		A        string
+		B        string
+		Contains bool
+		Overlaps bool
+	}{
+		{"10.0.0.0/8", "10.1.0.0/16", true, true},
+		{"10.1.0.0/16", "10.0.0.0/8", false, true},
+		{"10.0.0.0/24", "10.0.1.0/24", false, false},
+		{"10.0.0.0/31", "10.0.0.1/32", true, true},
+		{"10.0.0.0/32", "10.0.0.1/32", false, false},
+		{"10.0.0.0/32", "10.0.0.0/32", true, true},
+		{"fd00::/127", "fd00::1/128", true, true},
+		{"fd00::/128", "fd00::1/128", false, false},
+		{"::/0", "10.0.0.0/8", false, false},
+		{"0.0.0.0/0", "::ffff:0:0/96", false, false},
+	}
+	for _, testcase := range testcases {
+		a, _ := NewCidrAddressFromString(testcase.A)
+		b, _ := NewCidrAddressFromString(testcase.B)
+		assert.Equal(t, testcase.Contains, a.ContainsNetwork(b), testcase)
+		assert.Equal(t, testcase.Overlaps, a.Overlaps(b), testcase)
+		assert.Equal(t, testcase.Overlaps, b.Overlaps(a), testcase)
+	}
+	addr, _ := NewCidrAddressFromString("192.168.0.1/31")
+	assert.Equal(t, true, addr.Contains(net.ParseIP("192.168.0.0")))
+	assert.Equal(t, false, addr.Contains(net.ParseIP("192.168.0.2")))
+	assert.Equal(t, false, CidrAddress{}.Contains(net.ParseIP("192.168.0.2")))
+}
+
+func TestCidrNextPrev(t *testing.T) {
+	assert.Equal(t, "10.0.1.0", NextIP(net.ParseIP("10.0.0.255")).String())
+	assert.Equal(t, "10.0.0.255", PrevIP(net.ParseIP("10.0.1.0")).String())
+	assert.Equal(t, net.IP(nil), NextIP(net.ParseIP("255.255.255.255")))
+	assert.Equal(t, net.IP(nil), PrevIP(net.ParseIP("0.0.0.0")))
+	assert.Equal(t, "fd00::1:0", NextIP(net.ParseIP("fd00::ffff")).String())
+	assert.Equal(t, net.IP(nil), NextIP(net.ParseIP("ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff")))
+	assert.Equal(t, net.IP(nil), PrevIP(net.ParseIP("::")))
+	assert.Equal(t, net.IP(nil), NextIP(nil))
+
+	// 只在网络中移动
+	addr, _ := NewCidrAddressFromString("10.0.0.0/31")
+	next, ok := addr.Next()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "10.0.0.1/31", next.String())
+	_, ok = next.Next()
+	assert.Equal(t, false, ok)
+	_, ok = addr.Prev()
+	assert.Equal(t, false, ok)
+	addr, _ = NewCidrAddressFromString("fd00::1/128")
+	_, ok = addr.Next()
+	assert.Equal(t, false, ok)
+	_, ok = addr.Prev()
+	assert.Equal(t, false, ok)
+	addr, _ = NewCidrAddressFromString("fd00::1/127")
+	prev, ok := addr.Prev()
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "fd00::/127", prev.String())
+}
+
+func TestCidrSubnets(t *testing.T) {
+	addr, _ := NewCidrAddressFromString("10.0.0.1/24")
+	subnets, err := addr.Subnets(26)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.0.0.0/26", "10.0.0.64/26", "10.0.0.128/26", "10.0.0.192/26"},
+		lo.Map(subnets, func(item CidrAddress, _ int) string { return item.String() }))
+	subnets, err = addr.Subnets(24)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.0.0.0/24", subnets[0].String())
+	addr, _ = NewCidrAddressFromString("10.0.0.0/30")
+	subnets, err = addr.Subnets(32)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 4, len(subnets))
+	assert.Equal(t, "10.0.0.3/32", subnets[3].String())
+	_, err = addr.Subnets(33)
+	assert.ErrorIs(t, err, errs.WgInvalidPrefixError)
+	_, err = addr.Subnets(29)
+	assert.ErrorIs(t, err, errs.WgInvalidPrefixError)
+
+	addr, _ = NewCidrAddressFromString("fd00::/126")
+	subnets, err = addr.Subnets(127)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"fd00::/127", "fd00::2/127"},
+		lo.Map(subnets, func(item CidrAddress, _ int) string { return item.String() }))
+	subnets, err = addr.Subnets(128)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00::3/128", subnets[3].String())
+	addr, _ = NewCidrAddressFromString("fd00::/48")
+	subnets, err = addr.Subnets(64)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, MaxSubnets, len(subnets))
+	assert.Equal(t, "fd00:0:0:ffff::/64", subnets[MaxSubnets-1].String())
+	_, err = addr.Subnets(65)
+	assert.ErrorIs(t, err, errs.WgInvalidPrefixError)
+}
+
+func TestCidrSupernet(t *testing.T) {
+	addr, _ := NewCidrAddressFromString("10.1.2.3/32")
+	supernet, err := addr.Supernet(16)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.1.0.0/16", supernet.String())
+	supernet, err = addr.Supernet(32)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.1.2.3/32", supernet.String())
+	supernet, err = addr.Supernet(0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "0.0.0.0/0", supernet.String())
+	_, err = addr.Supernet(33)
+	assert.ErrorIs(t, err, errs.WgInvalidPrefixError)
+	addr, _ = NewCidrAddressFromString("fd00::1/128")
+	supernet, err = addr.Supernet(127)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00::/127", supernet.String())
+
+	var networks []CidrAddress
+	for _, cidr := range []string{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.0/24", "10.2.0.0/16", "::/0"} {
+		network, _ := NewCidrAddressFromString(cidr)
+		networks = append(networks, network)
+	}
+	for cidr, want := range map[string]string{
+		"10.1.2.3/32": "10.1.2.0/24",
+		"10.1.3.0/24": "10.1.0.0/16",
+		"10.0.0.0/8":  "10.0.0.0/8",
+		"11.0.0.0/8":  "",
+		"fd00::1/128": "::/0",
+		"10.1.0.0/15": "10.0.0.0/8",
+	} {
+		addr, _ = NewCidrAddressFromString(cidr)
+		supernet, ok := addr.LookupSupernet(networks)
+		assert.Equal(t, want != "", ok, cidr)
+		assert.Equal(t, want, supernet.String(), cidr)
+	}
+}
+
+func TestSubnetAddressesNormalize(t *testing.T) {
+	testcases := []struct {
+		Ori  string
+		Want string
+	}{
+		{"10.192.10.1/23", "10.192.10.0/23"},
+		{"10.0.0.1/24,10.0.0.2/24,10.0.0.0/24", "10.0.0.0/24"},
+		{"10.0.0.0/24,10.0.0.128/25,10.0.0.7/32", "10.0.0.0/24"},
+		{"10.0.1.0/24,10.0.0.0/24", "10.0.0.0/23"},
+		{"10.0.1.0/24,10.0.2.0/24", "10.0.1.0/24,10.0.2.0/24"},
+		{"10.0.0.0/24,10.0.1.0/24,10.0.2.0/24,10.0.3.0/24", "10.0.0.0/22"},
+		{"10.0.0.0/25,10.0.0.128/26,10.0.0.192/26,10.0.1.0/24", "10.0.0.0/23"},
+		{"10.0.0.0/32,10.0.0.1/32,10.0.0.2/31", "10.0.0.0/30"},
+		{"0.0.0.0/1,128.0.0.0/1", "0.0.0.0/0"},
+		{"fd00::1/64,10.0.0.1/8,fd00:0:0:1::/64", "10.0.0.0/8,fd00::/63"},
+		{"fd00::/128,fd00::1/128,fd00::2/127", "fd00::/126"},
+		{"::/1,8000::/1", "::/0"},
+	}
+	for _, testcase := range testcases {
+		subnets, err := NewSubnetAddressesFromString(testcase.Ori)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, testcase.Want, subnets.Normalize().String(), testcase.Ori)
+	}
+	assert.Equal(t, "", SubnetAddresses{}.Normalize().String())
+}
+
+func TestMarshalText(t *testing.T) {
+	var ip IpAddress
+	assert.Equal(t, nil, ip.UnmarshalText([]byte("192.168.222.1")))
+	assert.Equal(t, "192.168.222.1", ip.String())
+	text, err := ip.MarshalText()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.1", string(text))
+	assert.ErrorIs(t, ip.UnmarshalText([]byte("192.168.222")), errs.WgInvalidAddressError)
+	assert.Equal(t, nil, ip.UnmarshalText(nil))
+	assert.Equal(t, IpAddress(nil), ip)
+
+	var addr CidrAddress
+	assert.Equal(t, nil, addr.UnmarshalText([]byte("fd00::1/64")))
+	assert.Equal(t, "fd00::1/64", addr.String())
+	network := addr.GetNetwork()
+	assert.Equal(t, "fd00::/64", network.String())
+	assert.ErrorIs(t, addr.UnmarshalText([]byte("192.168.222.1/33")), errs.WgInvalidAddressError)
+	assert.Equal(t, nil, addr.UnmarshalText([]byte("")))
+	assert.Equal(t, true, addr.IsZero())
+
+	var subnets SubnetAddresses
+	assert.Equal(t, nil, subnets.UnmarshalText([]byte("10.0.0.0/24, 10.0.1.0/24")))
+	text, err = subnets.MarshalText()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.0.0.0/24,10.0.1.0/24", string(text))
+	assert.ErrorIs(t, subnets.UnmarshalText([]byte("10.0.0.0/24,x")), errs.WgInvalidAddressError)
+}
+
+func TestMarshalJSON(t *testing.T) {
+	type network struct {
+		PublicIp IpAddress       `json:"public_ip"`
+		Cidr     CidrAddress     `json:"cidr"`
+		Cidr6    CidrAddress     `json:"cidr6"`
+		SubNets  SubnetAddresses `json:"sub_nets"`
+	}
+	cidr, _ := NewCidrAddressFromString("192.168.222.1/24")
+	subnets, _ := NewSubnetAddressesFromString("10.0.0.0/24,fd00::/64")
+	data, err := json.Marshal(network{
+		PublicIp: *ParseIpAddressFromString("1.2.3.4"),
+		Cidr:     cidr,
+		SubNets:  subnets,
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, `{"public_ip":"1.2.3.4","cidr":"192.168.222.1/24","cidr6":null,"sub_nets":["10.0.0.0/24","fd00::/64"]}`, string(data))
+
+	var got network
+	assert.Equal(t, nil, json.Unmarshal(data, &got))
+	assert.Equal(t, "1.2.3.4", got.PublicIp.String())
+	assert.Equal(t, "192.168.222.1/24", got.Cidr.String())
+	assert.Equal(t, true, got.Cidr6.IsZero())
+	assert.Equal(t, "10.0.0.0/24,fd00::/64", got.SubNets.String())
+
+	// 子网地址同样可以使用以 , 分隔的字符串
+	assert.Equal(t, nil, json.Unmarshal([]byte(`{"sub_nets":"10.1.0.0/16, 10.2.0.0/16"}`), &got))
+	assert.Equal(t, "10.1.0.0/16,10.2.0.0/16", got.SubNets.String())
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"cidr":"192.168.222.1"}`), &got), errs.WgInvalidAddressError)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"public_ip":1234}`), &got), errs.WgInvalidAddressError)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"sub_nets":["10.0.0.0/24","x"]}`), &got), errs.WgInvalidAddressError)
+}
+
+func TestMarshalYAML(t *testing.T) {
+	type network struct {
+		PublicIp IpAddress       `yaml:"public_ip"`
+		Cidr     CidrAddress     `yaml:"cidr"`
+		SubNets  SubnetAddresses `yaml:"sub_nets"`
+	}
+	cidr, _ := NewCidrAddressFromString("192.168.222.1/24")
+	subnets, _ := NewSubnetAddressesFromString("10.0.0.0/24,fd00::/64")
+	data, err := yaml.Marshal(network{
+		PublicIp: *ParseIpAddressFromString("1.2.3.4"),
+		Cidr:     cidr,
+		SubNets:  subnets,
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "public_ip: 1.2.3.4\ncidr: 192.168.222.1/24\nsub_nets:\n    - 10.0.0.0/24\n    - fd00::/64\n", string(data))
+
+	var got network
+	assert.Equal(t, nil, yaml.Unmarshal(data, &got))
+	assert.Equal(t, "1.2.3.4", got.PublicIp.String())
+	assert.Equal(t, "192.168.222.1/24", got.Cidr.String())
+	assert.Equal(t, "10.0.0.0/24,fd00::/64", got.SubNets.String())
+
+	assert.Equal(t, nil, yaml.Unmarshal([]byte("sub_nets: 10.1.0.0/16, 10.2.0.0/16"), &got))
+	assert.Equal(t, "10.1.0.0/16,10.2.0.0/16", got.SubNets.String())
+	assert.ErrorIs(t, yaml.Unmarshal([]byte("cidr: 192.168.222.1/33"), &got), errs.WgInvalidAddressError)
+	assert.ErrorIs(t, yaml.Unmarshal([]byte("public_ip: [1.2.3.4]"), &got), errs.WgInvalidAddressError)
+	assert.ErrorIs(t, yaml.Unmarshal([]byte("sub_nets: [10.0.0.0/24, x]"), &got), errs.WgInvalidAddressError)
+}
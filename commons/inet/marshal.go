@@ -0,0 +1,207 @@
+package inet
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+)
+
+var _ encoding.TextMarshaler = IpAddress(nil)
+var _ encoding.TextUnmarshaler = (*IpAddress)(nil)
+var _ json.Marshaler = IpAddress(nil)
+var _ json.Unmarshaler = (*IpAddress)(nil)
+var _ yaml.Marshaler = IpAddress(nil)
+var _ yaml.Unmarshaler = (*IpAddress)(nil)
+var _ encoding.TextMarshaler = CidrAddress{}
+var _ encoding.TextUnmarshaler = (*CidrAddress)(nil)
+var _ json.Marshaler = CidrAddress{}
+var _ json.Unmarshaler = (*CidrAddress)(nil)
+var _ yaml.Marshaler = CidrAddress{}
+var _ yaml.Unmarshaler = (*CidrAddress)(nil)
+var _ encoding.TextMarshaler = SubnetAddresses{}
+var _ encoding.TextUnmarshaler = (*SubnetAddresses)(nil)
+var _ json.Marshaler = SubnetAddresses{}
+var _ json.Unmarshaler = (*SubnetAddresses)(nil)
+var _ yaml.Marshaler = SubnetAddresses{}
+var _ yaml.Unmarshaler = (*SubnetAddresses)(nil)
+
+// MarshalText 实现 encoding.TextMarshaler 接口，空地址为空字符串
+func (ip IpAddress) MarshalText() ([]byte, error) {
+	if ip == nil {
+		return []byte{}, nil
+	}
+	return []byte(ip.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口，空字符串为空地址
+func (ip *IpAddress) UnmarshalText(text []byte) error {
+	s := string(bytes.TrimSpace(text))
+	if s == "" {
+		*ip = nil
+		return nil
+	}
+	addr := net.ParseIP(s)
+	if addr == nil {
+		return fmt.Errorf("%w: %s", errs.WgInvalidAddressError, s)
+	}
+	*ip = IpAddress(addr)
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，空地址为 null
+func (ip IpAddress) MarshalJSON() ([]byte, error) {
+	if ip == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(ip.String())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口，接受字符串以及 null
+func (ip *IpAddress) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, ip)
+}
+
+// MarshalYAML 实现 yaml.Marshaler 接口
+func (ip IpAddress) MarshalYAML() (any, error) {
+	if ip == nil {
+		return "", nil
+	}
+	return ip.String(), nil
+}
+
+// UnmarshalYAML 实现 yaml.Unmarshaler 接口
+func (ip *IpAddress) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalYAMLText(value, ip)
+}
+
+// MarshalText 实现 encoding.TextMarshaler 接口，返回 "192.168.222.1/24" 形式的地址，空地址为空字符串
+func (addr CidrAddress) MarshalText() ([]byte, error) {
+	return []byte(addr.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口，空字符串为空地址
+func (addr *CidrAddress) UnmarshalText(text []byte) error {
+	s := string(bytes.TrimSpace(text))
+	if s == "" {
+		*addr = CidrAddress{}
+		return nil
+	}
+	cidr, err := NewCidrAddressFromString(s)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errs.WgInvalidAddressError, err)
+	}
+	*addr = cidr
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，空地址为 null
+func (addr CidrAddress) MarshalJSON() ([]byte, error) {
+	if addr.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(addr.String())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口，接受字符串以及 null
+func (addr *CidrAddress) UnmarshalJSON(data []byte) error {
+	return unmarshalJSONText(data, addr)
+}
+
+// MarshalYAML 实现 yaml.Marshaler 接口
+func (addr CidrAddress) MarshalYAML() (any, error) {
+	return addr.String(), nil
+}
+
+// UnmarshalYAML 实现 yaml.Unmarshaler 接口
+func (addr *CidrAddress) UnmarshalYAML(value *yaml.Node) error {
+	return unmarshalYAMLText(value, addr)
+}
+
+// MarshalText 实现 encoding.TextMarshaler 接口，返回以 , 分隔的子网地址
+func (subnet SubnetAddresses) MarshalText() ([]byte, error) {
+	return []byte(subnet.String()), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler 接口，解析以 , 分隔的子网地址，空字符串为空的子网
+func (subnet *SubnetAddresses) UnmarshalText(text []byte) error {
+	s := string(bytes.TrimSpace(text))
+	if s == "" {
+		*subnet = SubnetAddresses{}
+		return nil
+	}
+	subnets, err := NewSubnetAddressesFromString(s)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errs.WgInvalidAddressError, err)
+	}
+	*subnet = subnets
+	return nil
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，返回子网地址的数组
+func (subnet SubnetAddresses) MarshalJSON() ([]byte, error) {
+	return json.Marshal(subnet.addressStrings())
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口，接受子网地址的数组、以 , 分隔的字符串以及 null
+func (subnet *SubnetAddresses) UnmarshalJSON(data []byte) error {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		return unmarshalJSONText(data, subnet)
+	}
+	var addrs []CidrAddress
+	if err := json.Unmarshal(data, &addrs); err != nil {
+		return err
+	}
+	*subnet = NewSubnetAddresses(addrs...)
+	return nil
+}
+
+// MarshalYAML 实现 yaml.Marshaler 接口，返回子网地址的列表
+func (subnet SubnetAddresses) MarshalYAML() (any, error) {
+	return subnet.addressStrings(), nil
+}
+
+// UnmarshalYAML 实现 yaml.Unmarshaler 接口，接受子网地址的列表以及以 , 分隔的字符串
+func (subnet *SubnetAddresses) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.SequenceNode {
+		return unmarshalYAMLText(value, subnet)
+	}
+	var addrs []CidrAddress
+	if err := value.Decode(&addrs); err != nil {
+		return err
+	}
+	*subnet = NewSubnetAddresses(addrs...)
+	return nil
+}
+
+// addressStrings 所有子网地址的字符串表示
+func (subnet SubnetAddresses) addressStrings() []string {
+	return lo.Map(subnet.address, func(item CidrAddress, _ int) string {
+		return item.String()
+	})
+}
+
+// unmarshalJSONText 将 JSON 字符串按照文本解析，null 时不修改
+func unmarshalJSONText(data []byte, v encoding.TextUnmarshaler) error {
+	if string(bytes.TrimSpace(data)) == "null" {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("%w: %s", errs.WgInvalidAddressError, data)
+	}
+	return v.UnmarshalText([]byte(s))
+}
+
+// unmarshalYAMLText 将 YAML 的标量按照文本解析
+func unmarshalYAMLText(value *yaml.Node, v encoding.TextUnmarshaler) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("%w: 第 %d 行不是字符串", errs.WgInvalidAddressError, value.Line)
+	}
+	return v.UnmarshalText([]byte(value.Value))
+}
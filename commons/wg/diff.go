@@ -0,0 +1,128 @@
+package wg
+
+import (
+	"net"
+	"slices"
+	"time"
+
+	"github.com/samber/lo"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// PeerDiff 期望的节点配置与设备上实际节点的差异
+type PeerDiff struct {
+	Add    []wgtypes.PeerConfig // 设备上缺少的节点
+	Update []wgtypes.PeerConfig // 设备上配置不一致的节点
+	Remove []wgtypes.PeerConfig // 设备上多余的节点
+}
+
+// Empty 是否没有任何差异
+func (d PeerDiff) Empty() bool {
+	return len(d.Add) == 0 && len(d.Update) == 0 && len(d.Remove) == 0
+}
+
+// Filter 只保留 keep 返回 true 的节点的差异
+func (d PeerDiff) Filter(keep func(config wgtypes.PeerConfig) bool) PeerDiff {
+	return PeerDiff{
+		Add:    lo.Filter(d.Add, func(item wgtypes.PeerConfig, _ int) bool { return keep(item) }),
+		Update: lo.Filter(d.Update, func(item wgtypes.PeerConfig, _ int) bool { return keep(item) }),
+		Remove: lo.Filter(d.Remove, func(item wgtypes.PeerConfig, _ int) bool { return keep(item) }),
+	}
+}
+
+// DiffPeers 比较期望的节点配置与设备上实际的节点
+// 期望的配置中没有设置 Endpoint 时，不比较 Endpoint，因为设备会记录节点漫游后的地址
+func DiffPeers(desired []wgtypes.PeerConfig, actual []wgtypes.Peer) PeerDiff {
+	var diff PeerDiff
+	actualPeers := lo.SliceToMap(actual, func(item wgtypes.Peer) (wgtypes.Key, wgtypes.Peer) {
+		return item.PublicKey, item
+	})
+	desiredKeys := make(map[wgtypes.Key]bool)
+	for _, config := range desired {
+		desiredKeys[config.PublicKey] = true
+		peer, ok := actualPeers[config.PublicKey]
+		if !ok {
+			diff.Add = append(diff.Add, config)
+			continue
+		}
+		if !peerConfigEqual(config, peer) {
+			config.ReplaceAllowedIPs = true
+			diff.Update = append(diff.Update, config)
+		}
+	}
+	for _, peer := range actual {
+		if !desiredKeys[peer.PublicKey] {
+			diff.Remove = append(diff.Remove, wgtypes.PeerConfig{
+				PublicKey: peer.PublicKey,
+				Remove:    true,
+			})
+		}
+	}
+	return diff
+}
+
+// peerConfigEqual 设备上的节点是否与期望的配置一致
+func peerConfigEqual(config wgtypes.PeerConfig, peer wgtypes.Peer) bool {
+	var keepalive time.Duration
+	if config.PersistentKeepaliveInterval != nil {
+		keepalive = *config.PersistentKeepaliveInterval
+	}
+	if keepalive != peer.PersistentKeepaliveInterval {
+		return false
+	}
+	var psk wgtypes.Key
+	if config.PresharedKey != nil {
+		psk = *config.PresharedKey
+	}
+	if psk != peer.PresharedKey {
+		return false
+	}
+	if config.Endpoint != nil && (peer.Endpoint == nil || config.Endpoint.String() != peer.Endpoint.String()) {
+		return false
+	}
+	return slices.Equal(sortedNetworks(config.AllowedIPs), sortedNetworks(peer.AllowedIPs))
+}
+
+// sortedNetworks 将网络地址转换为排序后的字符串
+func sortedNetworks(networks []net.IPNet) []string {
+	result := lo.Map(networks, func(item net.IPNet, _ int) string {
+		return item.String()
+	})
+	slices.Sort(result)
+	return result
+}
+
+// RouteDiff 期望的路由与 wg 接口上实际路由的差异
+type RouteDiff struct {
+	Add    []net.IPNet // 缺少的路由
+	Remove []net.IPNet // 多余的路由
+}
+
+// Empty 是否没有任何差异
+func (d RouteDiff) Empty() bool {
+	return len(d.Add) == 0 && len(d.Remove) == 0
+}
+
+// DiffRoutes 比较期望的路由与实际的路由，重复的路由只会出现一次
+func DiffRoutes(desired []net.IPNet, actual []net.IPNet) RouteDiff {
+	var diff RouteDiff
+	actualRoutes := lo.SliceToMap(actual, func(item net.IPNet) (string, bool) {
+		return item.String(), true
+	})
+	desiredRoutes := make(map[string]bool)
+	for _, network := range desired {
+		if desiredRoutes[network.String()] {
+			continue
+		}
+		desiredRoutes[network.String()] = true
+		if !actualRoutes[network.String()] {
+			diff.Add = append(diff.Add, network)
+		}
+	}
+	for _, network := range actual {
+		if !desiredRoutes[network.String()] {
+			diff.Remove = append(diff.Remove, network)
+		}
+	}
+	return diff
+}
@@ -0,0 +1,92 @@
+package wg
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestDiffPeers(t *testing.T) {
+	_, key1, _ := GenerateWgKeyPairs()
+	_, key2, _ := GenerateWgKeyPairs()
+	_, key3, _ := GenerateWgKeyPairs()
+	_, key4, _ := GenerateWgKeyPairs()
+	keepalive := 25 * time.Second
+	nets1, _ := inet.NewSubnetAddressesFromString("192.168.222.2/32, 10.1.0.0/24")
+	nets1Reorder, _ := inet.NewSubnetAddressesFromString("10.1.0.0/24, 192.168.222.2/32")
+	nets2, _ := inet.NewSubnetAddressesFromString("192.168.222.3/32")
+	roamed := &net.UDPAddr{IP: net.ParseIP("5.6.7.8"), Port: 34567}
+
+	desired := []wgtypes.PeerConfig{
+		// 一致，AllowedIPs 顺序不同，设备上记录了漫游后的地址
+		{PublicKey: key1, AllowedIPs: nets1.GetNetworks(), PersistentKeepaliveInterval: &keepalive},
+		// AllowedIPs 不一致
+		{PublicKey: key2, AllowedIPs: nets2.GetNetworks()},
+		// 设备上缺少
+		{PublicKey: key3, AllowedIPs: nets2.GetNetworks()},
+	}
+	actual := []wgtypes.Peer{
+		{PublicKey: key1, AllowedIPs: nets1Reorder.GetNetworks(), PersistentKeepaliveInterval: keepalive, Endpoint: roamed},
+		{PublicKey: key2, AllowedIPs: nets1.GetNetworks()},
+		// 设备上多余
+		{PublicKey: key4},
+	}
+	diff := DiffPeers(desired, actual)
+	assert.Equal(t, false, diff.Empty())
+	assert.Equal(t, 1, len(diff.Add))
+	assert.Equal(t, key3, diff.Add[0].PublicKey)
+	assert.Equal(t, 1, len(diff.Update))
+	assert.Equal(t, key2, diff.Update[0].PublicKey)
+	assert.Equal(t, true, diff.Update[0].ReplaceAllowedIPs)
+	assert.Equal(t, 1, len(diff.Remove))
+	assert.Equal(t, key4, diff.Remove[0].PublicKey)
+	assert.Equal(t, true, diff.Remove[0].Remove)
+
+	// 保活时长以及 Endpoint 不一致
+	endpoint := &net.UDPAddr{IP: net.ParseIP("1.2.3.4"), Port: 51820}
+	diff = DiffPeers(
+		[]wgtypes.PeerConfig{{PublicKey: key1, Endpoint: endpoint, AllowedIPs: nets2.GetNetworks()}},
+		[]wgtypes.Peer{{PublicKey: key1, Endpoint: roamed, AllowedIPs: nets2.GetNetworks()}},
+	)
+	assert.Equal(t, 1, len(diff.Update))
+	diff = DiffPeers(
+		[]wgtypes.PeerConfig{{PublicKey: key1, AllowedIPs: nets2.GetNetworks()}},
+		[]wgtypes.Peer{{PublicKey: key1, PersistentKeepaliveInterval: keepalive, AllowedIPs: nets2.GetNetworks()}},
+	)
+	assert.Equal(t, 1, len(diff.Update))
+
+	psk, _ := wgtypes.GenerateKey()
+	diff = DiffPeers(
+		[]wgtypes.PeerConfig{{PublicKey: key1, PresharedKey: &psk, AllowedIPs: nets2.GetNetworks()}},
+		[]wgtypes.Peer{{PublicKey: key1, AllowedIPs: nets2.GetNetworks()}},
+	)
+	assert.Equal(t, 1, len(diff.Update))
+
+	// 完全一致
+	diff = DiffPeers(desired[:1], actual[:1])
+	assert.Equal(t, true, diff.Empty())
+}
+
+func TestDiffRoutes(t *testing.T) {
+	desired, _ := inet.NewSubnetAddressesFromString("10.1.0.0/24, 10.2.0.0/24, 10.1.0.0/24, fd00:1::/64")
+	actual, _ := inet.NewSubnetAddressesFromString("10.2.0.0/24, 10.3.0.0/24")
+
+	diff := DiffRoutes(desired.GetNetworks(), actual.GetNetworks())
+	assert.Equal(t, "[10.1.0.0/24 fd00:1::/64]", fmt.Sprintf("%s", lo.Map(diff.Add, func(item net.IPNet, _ int) string {
+		return item.String()
+	})))
+	assert.Equal(t, 1, len(diff.Remove))
+	assert.Equal(t, "10.3.0.0/24", diff.Remove[0].String())
+
+	// 完全一致
+	diff = DiffRoutes(actual.GetNetworks(), actual.GetNetworks())
+	assert.Equal(t, true, diff.Empty())
+	diff = DiffRoutes(nil, nil)
+	assert.Equal(t, true, diff.Empty())
+}
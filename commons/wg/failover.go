@@ -0,0 +1,111 @@
+package wg
+
+import (
+	"net"
+	"time"
+
+	"golang.zx2c4.com/wireguard/wgctrl"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// RelayFailover 节点侧的中继节点故障切换
+// 只有当前使用的中继节点配置了 AllowedIPs，备用的中继节点依靠保活维持握手
+// 当前的中继节点超过 Timeout 没有握手时，切换到优先级最高并且握手正常的中继节点
+// 当前的中继节点正常时不会切换回优先级更高的中继节点，避免来回切换
+type RelayFailover struct {
+	InterfaceName string        // wg 接口名
+	Relays        []wgtypes.Key // 按优先级排序的中继节点公钥
+	AllowedIPs    []net.IPNet   // 经过中继节点转发的地址
+	Timeout       time.Duration // 握手超时时长，需要大于 wg 重新握手的间隔
+	active        int           // 当前使用的中继节点
+	getPeers      func(interfaceName string) ([]wgtypes.Peer, error)
+	configure     func(interfaceName string, peers []wgtypes.PeerConfig) error
+}
+
+// NewRelayFailover 初始化中继节点故障切换，初始使用第一个中继节点
+func NewRelayFailover(interfaceName string, relays []wgtypes.Key, allowedIPs []net.IPNet, timeout time.Duration) *RelayFailover {
+	return &RelayFailover{
+		InterfaceName: interfaceName,
+		Relays:        relays,
+		AllowedIPs:    allowedIPs,
+		Timeout:       timeout,
+		getPeers:      GetWgPeers,
+		configure:     ConfigureWgPeers,
+	}
+}
+
+// WithDevice 替换获取以及修改 wg 设备上节点的方法，默认操作内核中的 wg 设备
+func (f *RelayFailover) WithDevice(getPeers func(interfaceName string) ([]wgtypes.Peer, error),
+	configure func(interfaceName string, peers []wgtypes.PeerConfig) error) *RelayFailover {
+	f.getPeers = getPeers
+	f.configure = configure
+	return f
+}
+
+// Active 当前使用的中继节点
+func (f *RelayFailover) Active() wgtypes.Key {
+	return f.Relays[f.active]
+}
+
+// SetActive 设置当前使用的中继节点，例如从服务端获取到的中继节点
+func (f *RelayFailover) SetActive(relay wgtypes.Key) bool {
+	for i, key := range f.Relays {
+		if key == relay {
+			f.active = i
+			return true
+		}
+	}
+	return false
+}
+
+// Check 检查中继节点的握手情况，需要时切换中继节点
+// 切换之后返回 true，节点需要调用 SwitchRelay 通知服务端新的中继节点
+func (f *RelayFailover) Check(now time.Time) (switched bool, err error) {
+	if len(f.Relays) == 0 {
+		return false, nil
+	}
+	peers, err := f.getPeers(f.InterfaceName)
+	if err != nil {
+		return false, err
+	}
+	handshakes := make(map[wgtypes.Key]time.Time)
+	for _, peer := range peers {
+		handshakes[peer.PublicKey] = peer.LastHandshakeTime
+	}
+	alive := func(key wgtypes.Key) bool {
+		handshake, ok := handshakes[key]
+		return ok && !handshake.IsZero() && now.Sub(handshake) <= f.Timeout
+	}
+	if alive(f.Relays[f.active]) {
+		return false, nil
+	}
+	next := f.active
+	for i, key := range f.Relays {
+		if alive(key) {
+			next = i
+			break
+		}
+	}
+	// 没有可用的中继节点时保持不变
+	if next == f.active {
+		return false, nil
+	}
+	if err = f.configure(f.InterfaceName, []wgtypes.PeerConfig{
+		{PublicKey: f.Relays[f.active], UpdateOnly: true, ReplaceAllowedIPs: true},
+		{PublicKey: f.Relays[next], UpdateOnly: true, ReplaceAllowedIPs: true, AllowedIPs: f.AllowedIPs},
+	}); err != nil {
+		return false, err
+	}
+	f.active = next
+	return true, nil
+}
+
+// ConfigureWgPeers 修改 wg 设备上的节点
+func ConfigureWgPeers(interfaceName string, peers []wgtypes.PeerConfig) (err error) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	return client.ConfigureDevice(interfaceName, wgtypes.Config{Peers: peers})
+}
@@ -0,0 +1,86 @@
+package wg
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+func TestRelayFailover(t *testing.T) {
+	_, relay1, _ := GenerateWgKeyPairs()
+	_, relay2, _ := GenerateWgKeyPairs()
+	_, relay3, _ := GenerateWgKeyPairs()
+	_, network, _ := net.ParseCIDR("192.168.222.0/24")
+	now := time.Now()
+	handshakes := map[wgtypes.Key]time.Time{
+		relay1: now.Add(-time.Minute),
+		relay2: now.Add(-time.Minute),
+		relay3: now.Add(-time.Minute),
+	}
+	allowedIps := make(map[wgtypes.Key][]net.IPNet)
+
+	failover := NewRelayFailover("wg0", []wgtypes.Key{relay1, relay2, relay3}, []net.IPNet{*network}, 3*time.Minute)
+	failover.getPeers = func(string) ([]wgtypes.Peer, error) {
+		var peers []wgtypes.Peer
+		for key, handshake := range handshakes {
+			peers = append(peers, wgtypes.Peer{PublicKey: key, LastHandshakeTime: handshake})
+		}
+		return peers, nil
+	}
+	failover.configure = func(_ string, peers []wgtypes.PeerConfig) error {
+		for _, peer := range peers {
+			assert.Equal(t, true, peer.UpdateOnly)
+			assert.Equal(t, true, peer.ReplaceAllowedIPs)
+			allowedIps[peer.PublicKey] = peer.AllowedIPs
+		}
+		return nil
+	}
+
+	// 当前的中继节点正常
+	switched, err := failover.Check(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, switched)
+	assert.Equal(t, relay1, failover.Active())
+
+	// 当前的中继节点没有握手，切换到下一个
+	handshakes[relay1] = now.Add(-10 * time.Minute)
+	switched, err = failover.Check(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, switched)
+	assert.Equal(t, relay2, failover.Active())
+	assert.Equal(t, 0, len(allowedIps[relay1]))
+	assert.Equal(t, "192.168.222.0/24", allowedIps[relay2][0].String())
+
+	// 所有的中继节点都没有握手时保持不变
+	handshakes[relay2] = time.Time{}
+	handshakes[relay3] = now.Add(-10 * time.Minute)
+	switched, err = failover.Check(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, switched)
+	assert.Equal(t, relay2, failover.Active())
+
+	// 优先级更高的中继节点恢复后，当前的中继节点正常时不切换回去
+	handshakes[relay1] = now
+	handshakes[relay2] = now.Add(-time.Minute)
+	switched, err = failover.Check(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, switched)
+	assert.Equal(t, relay2, failover.Active())
+
+	// 当前的中继节点没有握手时切换到优先级最高并且握手正常的中继节点
+	handshakes[relay2] = now.Add(-10 * time.Minute)
+	handshakes[relay3] = now
+	switched, err = failover.Check(now)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, switched)
+	assert.Equal(t, relay1, failover.Active())
+	assert.Equal(t, 0, len(allowedIps[relay2]))
+
+	assert.Equal(t, true, failover.SetActive(relay3))
+	assert.Equal(t, relay3, failover.Active())
+	_, unknown, _ := GenerateWgKeyPairs()
+	assert.Equal(t, false, failover.SetActive(unknown))
+}
@@ -0,0 +1,94 @@
+package wg
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// RouteProtocol wg-tool 添加的路由使用的协议号，用于区分其他程序或者手动添加的路由
+const RouteProtocol = 0x57
+
+// RouteConfig 经过 wg 接口转发的路由配置
+type RouteConfig struct {
+	InterfaceName string // wg 接口名
+	Table         int    // 路由表 ID，为 0 时使用 main 表
+	Metric        int    // 路由的优先级
+}
+
+// getTable 获取路由表 ID
+func (c RouteConfig) getTable() int {
+	if c.Table == 0 {
+		return unix.RT_TABLE_MAIN
+	}
+	return c.Table
+}
+
+// toRoute 转换为经过 wg 接口的路由
+func (c RouteConfig) toRoute(link netlink.Link, network net.IPNet) netlink.Route {
+	return netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Scope:     netlink.SCOPE_LINK,
+		Dst:       &network,
+		Protocol:  RouteProtocol,
+		Priority:  c.Metric,
+		Table:     c.getTable(),
+	}
+}
+
+// AddRoutes 添加经过 wg 接口的路由，已经存在的路由会被忽略
+func AddRoutes(config RouteConfig, networks []net.IPNet) (err error) {
+	link, err := netlink.LinkByName(config.InterfaceName)
+	if err != nil {
+		return
+	}
+	for _, network := range networks {
+		route := config.toRoute(link, network)
+		if err = netlink.RouteAdd(&route); err != nil && !errors.Is(err, syscall.EEXIST) {
+			return
+		}
+	}
+	return nil
+}
+
+// DelRoutes 删除经过 wg 接口的路由，不存在的路由会被忽略
+func DelRoutes(config RouteConfig, networks []net.IPNet) (err error) {
+	link, err := netlink.LinkByName(config.InterfaceName)
+	if err != nil {
+		return
+	}
+	for _, network := range networks {
+		route := config.toRoute(link, network)
+		if err = netlink.RouteDel(&route); err != nil && !errors.Is(err, syscall.ESRCH) {
+			return
+		}
+	}
+	return nil
+}
+
+// GetRoutes 获取 wg-tool 在 wg 接口上添加的路由
+func GetRoutes(config RouteConfig) (networks []net.IPNet, err error) {
+	link, err := netlink.LinkByName(config.InterfaceName)
+	if err != nil {
+		return
+	}
+	filter := &netlink.Route{
+		LinkIndex: link.Attrs().Index,
+		Protocol:  RouteProtocol,
+		Table:     config.getTable(),
+	}
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, filter,
+		netlink.RT_FILTER_OIF|netlink.RT_FILTER_PROTOCOL|netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return
+	}
+	for _, route := range routes {
+		if route.Dst != nil {
+			networks = append(networks, *route.Dst)
+		}
+	}
+	return networks, nil
+}
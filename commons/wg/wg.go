@@ -4,6 +4,7 @@ package wg
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/onesaltedseafish/wg-tool/commons/errs"
 	"github.com/samber/lo"
@@ -14,10 +15,11 @@ import (
 
 // WgServerConfig 用于初始化 Wg server peer 的配置
 type WgServerConfig struct {
-	InterfaceName string       // wg 接口名
-	PrivateKey    string       // wg 私钥
-	ListenPort    int          // 监听的端口
-	Address       netlink.Addr // WG IP 地址
+	InterfaceName string        // wg 接口名
+	PrivateKey    string        // wg 私钥
+	ListenPort    int           // 监听的端口
+	Address       netlink.Addr  // WG IP 地址
+	Address6      *netlink.Addr // WG IPv6 地址，双栈时设置
 }
 
 // WgPeerConfig 用户初始化 peer 的配置
@@ -71,8 +73,45 @@ func AddWireguardInterface(config WgServerConfig) (err error) {
 	if err = netlink.AddrAdd(wgLink, &config.Address); err != nil {
 		return
 	}
+	if config.Address6 != nil {
+		if err = netlink.AddrAdd(wgLink, config.Address6); err != nil {
+			return
+		}
+	}
+
+	if err = configureWgDevice(config); err != nil {
+		return
+	}
+	return netlink.LinkSetUp(wgLink)
+}
+
+// SetupWireguardInterface 启动 wg 接口
+// 接口不存在时新建接口，已经存在时重新设置地址、私钥以及端口
+func SetupWireguardInterface(config WgServerConfig) (err error) {
+	link, err := netlink.LinkByName(config.InterfaceName)
+	if err != nil {
+		var notFound netlink.LinkNotFoundError
+		if errors.As(err, &notFound) {
+			return AddWireguardInterface(config)
+		}
+		return
+	}
+	if err = netlink.AddrReplace(link, &config.Address); err != nil {
+		return
+	}
+	if config.Address6 != nil {
+		if err = netlink.AddrReplace(link, config.Address6); err != nil {
+			return
+		}
+	}
+	if err = configureWgDevice(config); err != nil {
+		return
+	}
+	return netlink.LinkSetUp(link)
+}
 
-	// 配置 wireguard 端口
+// configureWgDevice 配置 wireguard 的私钥以及端口
+func configureWgDevice(config WgServerConfig) (err error) {
 	c := wgtypes.Config{}
 	priKey, err := wgtypes.ParseKey(config.PrivateKey)
 	if err != nil {
@@ -108,6 +147,9 @@ func DeleteWireguardInterface(name string) (err error) {
 // AddWgPeer 添加节点
 func AddWgPeer(config WgPeerConfig) (err error) {
 	client, err := wgctrl.New()
+	if err != nil {
+		return
+	}
 	defer client.Close()
 
 	device, err := client.Device(config.InterfaceName)
@@ -124,11 +166,69 @@ func AddWgPeer(config WgPeerConfig) (err error) {
 	})
 }
 
+// UpdateWgPeer 修改已经存在的节点
+// AllowedIPs 会被完全替换，没有设置保活时长或者预共享密钥时关闭对应的功能
+func UpdateWgPeer(config WgPeerConfig) (err error) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	peerConfig := config.PeerConfig
+	peerConfig.UpdateOnly = true
+	peerConfig.ReplaceAllowedIPs = true
+	if peerConfig.PersistentKeepaliveInterval == nil {
+		var disable time.Duration
+		peerConfig.PersistentKeepaliveInterval = &disable
+	}
+	if peerConfig.PresharedKey == nil {
+		var disable wgtypes.Key
+		peerConfig.PresharedKey = &disable
+	}
+	return client.ConfigureDevice(config.InterfaceName, wgtypes.Config{
+		Peers: []wgtypes.PeerConfig{peerConfig},
+	})
+}
+
+// RemoveWgPeer 删除节点
+func RemoveWgPeer(interfaceName string, publicKey wgtypes.Key) (err error) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	return client.ConfigureDevice(interfaceName, wgtypes.Config{
+		Peers: []wgtypes.PeerConfig{
+			{
+				PublicKey: publicKey,
+				Remove:    true,
+			},
+		},
+	})
+}
+
+// GetWgPeers 获取 wg 设备上所有的节点
+func GetWgPeers(interfaceName string) (peers []wgtypes.Peer, err error) {
+	client, err := wgctrl.New()
+	if err != nil {
+		return
+	}
+	defer client.Close()
+
+	device, err := client.Device(interfaceName)
+	if err != nil {
+		return
+	}
+	return device.Peers, nil
+}
+
 // Peer2PeerConfig 从获取到的设备的 peer 转为需要设置的 peerConfigs
 func Peer2PeerConfig(p wgtypes.Peer) wgtypes.PeerConfig {
 	peerConfig := wgtypes.PeerConfig{}
 	peerConfig.PublicKey = p.PublicKey
-	if p.PresharedKey.String() != "" {
+	if p.PresharedKey != (wgtypes.Key{}) {
 		peerConfig.PresharedKey = &p.PresharedKey
 	}
 	peerConfig.Endpoint = p.Endpoint
@@ -60,6 +60,25 @@ func TestAddWireguardInterface(t *testing.T) {
 	assert.Equal(t, nil, err)
 }
 
+// 只在 linux 环境下进行测试
+// 接口已经存在时可以重复启动
+func TestSetupWireguardInterface(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+	err := SetupWireguardInterface(serverConfig)
+	assert.Equal(t, nil, err)
+	err = SetupWireguardInterface(serverConfig)
+	assert.Equal(t, nil, err)
+	link, err := netlink.LinkByName(interfaceName)
+	if assert.Equal(t, nil, err) {
+		assert.NotEqual(t, 0, link.Attrs().Flags&net.FlagUp)
+	}
+	// 删除 wg interface
+	err = DeleteWireguardInterface(interfaceName)
+	assert.Equal(t, nil, err)
+}
+
 // 只在 linux 环境下进行测试
 // 主要测试在设置 wireguard 失败的时候
 // 时候会将添加的端口删除
@@ -113,3 +132,87 @@ func TestAddWireguardPeers(t *testing.T) {
 	err = DeleteWireguardInterface(interfaceName)
 	assert.Equal(t, nil, err)
 }
+
+// 只在 linux 下进行测试
+func TestUpdateWireguardPeers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+	err := AddWireguardInterface(serverConfig)
+	assert.Equal(t, nil, err)
+	err = AddWgPeer(peerConfig)
+	assert.Equal(t, nil, err)
+	// 替换 AllowedIPs 并关闭保活
+	newSubnet, _ := inet.NewSubnetAddressesFromString("172.16.0.1/24")
+	err = UpdateWgPeer(WgPeerConfig{
+		InterfaceName: interfaceName,
+		PeerConfig: wgtypes.PeerConfig{
+			PublicKey:  peerPubKey,
+			AllowedIPs: newSubnet.GetNetworks(),
+		},
+	})
+	assert.Equal(t, nil, err)
+	client, err := wgctrl.New()
+	assert.Equal(t, nil, err)
+	defer client.Close()
+	device, err := client.Device(interfaceName)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(device.Peers))
+	assert.Equal(t, fmt.Sprintf("%s", newSubnet.GetNetworks()), fmt.Sprintf("%s", device.Peers[0].AllowedIPs))
+	assert.Equal(t, time.Duration(0), device.Peers[0].PersistentKeepaliveInterval)
+	// 删除wg接口
+	err = DeleteWireguardInterface(interfaceName)
+	assert.Equal(t, nil, err)
+}
+
+// 只在 linux 下进行测试
+func TestRemoveWireguardPeers(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+	// 添加 server peer 以及 peer 节点
+	err := AddWireguardInterface(serverConfig)
+	assert.Equal(t, nil, err)
+	err = AddWgPeer(peerConfig)
+	assert.Equal(t, nil, err)
+	// 删除 peer 节点
+	err = RemoveWgPeer(interfaceName, peerPubKey)
+	assert.Equal(t, nil, err)
+	client, err := wgctrl.New()
+	assert.Equal(t, nil, err)
+	defer client.Close()
+	device, err := client.Device(interfaceName)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(device.Peers))
+	// 删除wg接口
+	err = DeleteWireguardInterface(interfaceName)
+	assert.Equal(t, nil, err)
+}
+
+// 只在 linux 下进行测试
+func TestWireguardRoutes(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+	err := AddWireguardInterface(serverConfig)
+	assert.Equal(t, nil, err)
+	routeConfig := RouteConfig{InterfaceName: interfaceName, Table: 100, Metric: 10}
+	subnets, _ := inet.NewSubnetAddressesFromString("172.16.0.0/24, 172.16.1.0/24")
+	// 重复添加不会报错
+	err = AddRoutes(routeConfig, subnets.GetNetworks())
+	assert.Equal(t, nil, err)
+	err = AddRoutes(routeConfig, subnets.GetNetworks())
+	assert.Equal(t, nil, err)
+	routes, err := GetRoutes(routeConfig)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, DiffRoutes(subnets.GetNetworks(), routes).Empty())
+	// 删除路由
+	err = DelRoutes(routeConfig, subnets.GetNetworks()[:1])
+	assert.Equal(t, nil, err)
+	routes, err = GetRoutes(routeConfig)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, DiffRoutes(subnets.GetNetworks()[1:], routes).Empty())
+	// 删除wg接口
+	err = DeleteWireguardInterface(interfaceName)
+	assert.Equal(t, nil, err)
+}
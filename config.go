@@ -3,8 +3,12 @@ package config
 
 import (
 	"context"
+	"net"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -26,17 +30,110 @@ var (
 )
 
 type config struct {
-	Token        string `mapstructure:"token" validate:"required"`
-	SqlitePath   string `mapstructure:"sqlite"`
-	LogLevel     string `mapstructure:"log_level"`
-	LogDirectory string `mapstructure:"log_dir"`
+	Token         string          `mapstructure:"token" validate:"required"`
+	Listen        string          `mapstructure:"listen" validate:"hostname_port"`
+	TlsCert       string          `mapstructure:"tls_cert" validate:"required_with=TlsKey"`          // 服务端证书，为空时不开启 TLS
+	TlsKey        string          `mapstructure:"tls_key" validate:"required_with=TlsCert"`          // 服务端私钥
+	TlsClientCA   string          `mapstructure:"tls_client_ca" validate:"excluded_without=TlsCert"` // 客户端 CA，不为空时开启双向认证
+	DisableKeygen bool            `mapstructure:"disable_server_keygen"`                             // 不允许服务端为节点生成密钥对
+	RequirePsk    bool            `mapstructure:"require_preshared_key"`                             // 每个节点都必须使用预共享密钥
+	Network       networkConfig   `mapstructure:"network"`
+	Reconcile     reconcileConfig `mapstructure:"reconcile"`
+	Route         routeConfig     `mapstructure:"route"`
+	Lease         leaseConfig     `mapstructure:"lease"`
+	Subnet        subnetConfig    `mapstructure:"subnet"`
+	SqlitePath    string          `mapstructure:"sqlite"`
+	LogLevel      string          `mapstructure:"log_level"`
+	LogDirectory  string          `mapstructure:"log_dir"`
 }
 
+// networkConfig 中继节点以及所在网络的配置
+type networkConfig struct {
+	PeerName      string           `mapstructure:"name" validate:"required"`                                         // 中继节点名
+	InterfaceName string           `mapstructure:"interface" validate:"required,max=15"`                             // wg 接口名
+	Cidr          inet.CidrAddress `mapstructure:"cidr" validate:"required"`                                         // 中继节点的地址，同时决定了整个网络的地址范围
+	Cidr6         inet.CidrAddress `mapstructure:"cidr6"`                                                            // 中继节点的 IPv6 地址，为空时不开启双栈
+	ListenPort    uint16           `mapstructure:"listen_port" validate:"gt=0"`                                      // 监听端口
+	PublicIp      inet.IpAddress   `mapstructure:"public_ip" validate:"required"`                                    // 公网 IP
+	KeepAlive     int              `mapstructure:"keepalive" validate:"gte=0,lte=65535"`                             // 节点默认的保活时长，单位秒
+	Topology      string           `mapstructure:"topology" validate:"oneof=hub mesh hybrid"`                        // 网络拓扑
+	Allocator     string           `mapstructure:"allocator" validate:"omitempty,oneof=sequential random"`           // IPv4 地址的分配策略
+	Allocator6    string           `mapstructure:"allocator6" validate:"omitempty,oneof=sequential random key-hash"` // IPv6 地址的分配策略
+	Pool          poolConfig       `mapstructure:"pool"`                                                             // IPv4 地址池的范围
+	Pool6         poolConfig       `mapstructure:"pool6"`                                                            // IPv6 地址池的范围
+}
+
+// poolConfig 地址池可以分配的地址范围
+type poolConfig struct {
+	Start   string   `mapstructure:"start" validate:"omitempty,ip"` // 第一个可以分配的地址，为空时从网络中第一个主机地址开始
+	End     string   `mapstructure:"end" validate:"omitempty,ip"`   // 最后一个可以分配的地址，为空时到网络中最后一个主机地址
+	Exclude []string `mapstructure:"exclude"`                       // 不分配的地址或者地址范围
+}
+
+// reconcileConfig 数据库与 wg 设备之间的同步配置
+type reconcileConfig struct {
+	Interval time.Duration `mapstructure:"interval" validate:"gte=0"` // 同步间隔，为 0 时只在启动时同步
+	DryRun   bool          `mapstructure:"dry_run"`                   // 只输出需要修正的内容，不修改设备
+}
+
+// routeConfig SubNet 节点子网的路由配置
+type routeConfig struct {
+	Table  int `mapstructure:"table" validate:"gte=0"`  // 路由表 ID，为 0 时使用 main 表
+	Metric int `mapstructure:"metric" validate:"gte=0"` // 路由的优先级
+}
+
+// leaseConfig 节点地址的租约配置
+type leaseConfig struct {
+	Duration     time.Duration `mapstructure:"duration" validate:"gte=0"`      // IPv4 地址的租约时长，为 0 时不会过期
+	Duration6    time.Duration `mapstructure:"duration6" validate:"gte=0"`     // IPv6 地址的租约时长，为 0 时不会过期
+	ReapInterval time.Duration `mapstructure:"reap_interval" validate:"gte=0"` // 回收到期租约的间隔，为 0 时不回收
+}
+
+// subnetConfig 为 SubNet 节点分配子网的配置
+type subnetConfig struct {
+	Supernet inet.CidrAddress   `mapstructure:"supernet"`                        // 分配子网的超网，为空时不分配
+	Prefix   int                `mapstructure:"prefix" validate:"gte=0,lte=128"` // 默认分配的子网的前缀长度
+	Deny     []inet.CidrAddress `mapstructure:"deny" validate:"dive,required"`   // 节点的子网不能使用的网络
+}
+
+// DeniedNetworks 节点的子网不能使用的网络
+func (c subnetConfig) DeniedNetworks() []net.IPNet {
+	networks := make([]net.IPNet, 0, len(c.Deny))
+	for _, cidr := range c.Deny {
+		networks = append(networks, cidr.GetNetwork())
+	}
+	return networks
+}
+
+// decodeHook 在 viper 默认的 hook 之外解析实现了 encoding.TextUnmarshaler 的类型
+var decodeHook = mapstructure.ComposeDecodeHookFunc(
+	mapstructure.TextUnmarshallerHookFunc(),
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+)
+
 func newConfig() config {
 	return config{
+		Listen:       "0.0.0.0:50051",
 		SqlitePath:   "./wg-tool-default.db",
 		LogLevel:     "info",
 		LogDirectory: "./logs",
+		Network: networkConfig{
+			PeerName:      "relay",
+			InterfaceName: "wg0",
+			ListenPort:    51820,
+			KeepAlive:     25,
+			Topology:      "hub",
+		},
+		Reconcile: reconcileConfig{
+			Interval: time.Minute,
+		},
+		Lease: leaseConfig{
+			ReapInterval: time.Minute,
+		},
+		Subnet: subnetConfig{
+			Prefix: 24,
+		},
 	}
 }
 
@@ -52,8 +149,8 @@ func InitConfig() {
 	if err := viper.ReadInConfig(); err != nil {
 		logger.Fatal("ReadInConfig failed", zap.Error(err))
 	}
-	// unmarshal
-	if err := viper.Unmarshal(&Config); err != nil {
+	// unmarshal，地址等实现了 encoding.TextUnmarshaler 的类型直接从字符串中解析
+	if err := viper.Unmarshal(&Config, viper.DecodeHook(decodeHook)); err != nil {
 		logger.Fatal("unmarshal config failed", zap.Error(err))
 	}
 	// 校验 config
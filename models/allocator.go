@@ -0,0 +1,231 @@
+package models
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+
+	"github.com/onesaltedseafish/go-utils/simulate/dhcp"
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// AllocateStrategy 地址分配策略
+type AllocateStrategy string
+
+const (
+	StrategySequential AllocateStrategy = "sequential" // 按顺序分配
+	StrategyRandom     AllocateStrategy = "random"     // 在网络中随机分配
+	StrategyKeyHash    AllocateStrategy = "key-hash"   // 根据公钥的哈希生成 IPv6 地址
+)
+
+const (
+	randomAttempts  = 64  // 随机分配时最多尝试的次数
+	keyHashAttempts = 256 // 哈希冲突时最多尝试的次数
+	keyHashMinBits  = 64  // 根据公钥生成地址时网络中至少需要的主机位数
+)
+
+// ParseAllocateStrategy 解析地址分配策略，为空时按顺序分配
+func ParseAllocateStrategy(s string) (AllocateStrategy, error) {
+	switch strategy := AllocateStrategy(s); strategy {
+	case "":
+		return StrategySequential, nil
+	case StrategySequential, StrategyRandom, StrategyKeyHash:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("%w: %s", errs.WgInvalidStrategyError, s)
+}
+
+// CheckAllocateStrategy 检查网络是否可以使用该分配策略
+// 根据公钥生成地址只能用于 /64 或者更大的 IPv6 网络，保证哈希冲突的概率足够低
+func CheckAllocateStrategy(strategy AllocateStrategy, network net.IPNet) error {
+	if strategy != StrategyKeyHash {
+		return nil
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 8*net.IPv6len || bits-ones < keyHashMinBits {
+		return fmt.Errorf("%w: %s 不能用于 %s", errs.WgInvalidStrategyError, strategy, network.String())
+	}
+	return nil
+}
+
+// AddressAllocator 地址分配器
+type AddressAllocator interface {
+	// AllocateAddress 为节点分配地址，mac 标识地址池中的节点，publicKey 为节点的公钥
+	AllocateAddress(mac net.HardwareAddr, publicKey string) (net.IP, error)
+}
+
+// NewAddressAllocator 根据分配策略初始化地址池的分配器
+func NewAddressAllocator(strategy AllocateStrategy, storage *DhcpStorage) (AddressAllocator, error) {
+	network := storage.cidr.GetNetwork()
+	if err := CheckAllocateStrategy(strategy, network); err != nil {
+		return nil, err
+	}
+	switch strategy {
+	case StrategySequential:
+		return sequentialAllocator{storage: storage}, nil
+	case StrategyRandom:
+		return randomAllocator{storage: storage}, nil
+	case StrategyKeyHash:
+		return keyHashAllocator{storage: storage}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", errs.WgInvalidStrategyError, strategy)
+}
+
+// sequentialAllocator 在地址池的范围中按顺序分配地址
+// 依次尝试节点之前使用的地址、最后分配的地址之后的地址以及已经释放的地址，都没有时返回 errs.WgPoolExhaustedError
+type sequentialAllocator struct {
+	storage *DhcpStorage
+}
+
+func (a sequentialAllocator) AllocateAddress(mac net.HardwareAddr, _ string) (net.IP, error) {
+	ip, err := a.storage.GetAddressWithMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	if ip != nil && a.storage.inPool(ip) {
+		return ip, a.storage.SetAddressWithMAC(ip, mac)
+	}
+	if ip, err = a.nextAddress(); err != nil {
+		return nil, err
+	}
+	if ip == nil {
+		if ip, err = a.storage.GetOneUnusedAddress(); err != nil {
+			return nil, err
+		}
+	}
+	if ip == nil {
+		return nil, a.storage.exhaustedError()
+	}
+	return ip, a.storage.SetAddressWithMAC(ip, mac)
+}
+
+// nextAddress 从最后分配的地址之后查找没有被使用的地址，直接跳过排除的范围
+func (a sequentialAllocator) nextAddress() (net.IP, error) {
+	last, err := a.storage.GetLastAddress()
+	if err != nil {
+		return nil, err
+	}
+	end := a.storage.lastAddress()
+	for ip := dhcp.IpAdd(last, 1); compareIP(ip, end) <= 0 && compareIP(ip, last) > 0; ip = dhcp.IpAdd(ip, 1) {
+		if r, excluded := a.storage.exclusion(ip); excluded {
+			ip = r.End
+			continue
+		}
+		used, err := a.storage.IsUsed(ip)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			return ip, nil
+		}
+	}
+	return nil, nil
+}
+
+// randomAllocator 在地址池的范围中随机分配地址，节点重复分配时返回之前的地址
+type randomAllocator struct {
+	storage *DhcpStorage
+}
+
+func (a randomAllocator) AllocateAddress(mac net.HardwareAddr, _ string) (net.IP, error) {
+	ip, err := a.storage.GetAddressWithMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	if ip != nil && a.storage.inPool(ip) {
+		return ip, a.storage.SetAddressWithMAC(ip, mac)
+	}
+	first := a.storage.firstAddress()
+	size := rangeSize(first, a.storage.lastAddress())
+	for i := 0; i < randomAttempts; i++ {
+		offset, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return nil, err
+		}
+		ip = offsetAddress(first, offset)
+		if !a.storage.inPool(ip) {
+			continue
+		}
+		used, err := a.storage.IsUsed(ip)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			return ip, a.storage.SetAddressWithMAC(ip, mac)
+		}
+	}
+	// 多次随机都冲突时说明地址池快用完了，按顺序查找剩余的地址
+	return sequentialAllocator{storage: a.storage}.AllocateAddress(mac, "")
+}
+
+// keyHashAllocator 根据公钥的哈希生成地址，同一个公钥总是得到同样的地址
+// 地址已经被其他节点使用时依次尝试 sha256(公钥 || 序号) 直到找到可用的地址
+type keyHashAllocator struct {
+	storage *DhcpStorage
+}
+
+func (a keyHashAllocator) AllocateAddress(mac net.HardwareAddr, publicKey string) (net.IP, error) {
+	key, err := wgtypes.ParseKey(publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errs.WgInvalidKeyError, err)
+	}
+	network := a.storage.cidr.GetNetwork()
+	for i := 0; i < keyHashAttempts; i++ {
+		sum := sha256.Sum256(append(key[:], byte(i)))
+		ip := hostAddress(network, sum[:])
+		if !a.storage.inPool(ip) {
+			continue
+		}
+		reserved, err := a.storage.isReserved(ip)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			continue
+		}
+		record, err := a.storage.getRecord(ip)
+		if err != nil {
+			return nil, err
+		}
+		// 地址没有被使用或者已经分配给该节点
+		if !record.Enable || bytes.Equal(record.HardwareAddr, mac) {
+			return ip, a.storage.SetAddressWithMAC(ip, mac)
+		}
+	}
+	return nil, a.storage.exhaustedError()
+}
+
+// getRecord 获取地址的记录，不存在时返回空记录
+func (s *DhcpStorage) getRecord(ip net.IP) (DhcpClient, error) {
+	record := DhcpClient{
+		CIDR:    s.cidr,
+		Address: inet.IpAddress(ip),
+	}
+	err := s.db.Where(&record).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DhcpClient{}, nil
+	}
+	return record, err
+}
+
+// hostAddress 使用 host 填充网络中的主机位
+func hostAddress(network net.IPNet, host []byte) net.IP {
+	ip := make(net.IP, len(network.IP))
+	for i := range ip {
+		ip[i] = network.IP[i] | (host[i] &^ network.Mask[i])
+	}
+	return ip
+}
+
+// offsetAddress 地址加上 offset 之后的地址
+func offsetAddress(ip net.IP, offset *big.Int) net.IP {
+	n := new(big.Int).Add(new(big.Int).SetBytes(ip), offset)
+	return n.FillBytes(make(net.IP, len(ip)))
+}
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"gorm.io/gorm"
+)
+
+// ConflictKind 与子网重叠的网络的类型
+type ConflictKind string
+
+const (
+	ConflictPeer    ConflictKind = "peer"    // 其他节点的子网
+	ConflictOverlay ConflictKind = "overlay" // 中继节点所在的覆盖网络
+	ConflictDenied  ConflictKind = "denied"  // 配置中禁止使用的网络
+)
+
+// SubnetConflict 节点的子网与其他网络重叠
+type SubnetConflict struct {
+	PeerName  string       // 子网所属的节点
+	Network   net.IPNet    // 节点的子网
+	Kind      ConflictKind // 重叠网络的类型
+	Owner     string       // 重叠网络所属的节点，只有 ConflictPeer 时不为空
+	Conflicts net.IPNet    // 重叠的网络
+}
+
+func (c SubnetConflict) String() string {
+	subnet := fmt.Sprintf("节点 %s 的子网 %s", c.PeerName, c.Network.String())
+	switch c.Kind {
+	case ConflictOverlay:
+		return fmt.Sprintf("%s 与覆盖网络 %s 重叠", subnet, c.Conflicts.String())
+	case ConflictDenied:
+		return fmt.Sprintf("%s 与禁止使用的网络 %s 重叠", subnet, c.Conflicts.String())
+	}
+	return fmt.Sprintf("%s 与节点 %s 的子网 %s 重叠", subnet, c.Owner, c.Conflicts.String())
+}
+
+// ownedNetwork 已经被使用的网络以及它的来源
+type ownedNetwork struct {
+	kind    ConflictKind
+	owner   string
+	network net.IPNet
+}
+
+// CheckSubnetConflicts 检查节点的子网是否与覆盖网络、禁止使用的网络、其他节点的子网或者彼此重叠
+// 重叠时返回 errs.WgSubnetConflictError，错误信息中包含重叠的节点以及网络
+func CheckSubnetConflicts(db *gorm.DB, peerName string, subnets []net.IPNet, denied []net.IPNet) error {
+	owned, err := ownedNetworks(db, denied)
+	if err != nil {
+		return err
+	}
+	for i, subnet := range subnets {
+		for _, other := range owned {
+			if other.kind == ConflictPeer && other.owner == peerName {
+				continue
+			}
+			if networksOverlap(subnet, other.network) {
+				return subnetConflictError(SubnetConflict{PeerName: peerName, Network: subnet,
+					Kind: other.kind, Owner: other.owner, Conflicts: other.network})
+			}
+		}
+		for _, other := range subnets[:i] {
+			if networksOverlap(subnet, other) {
+				return subnetConflictError(SubnetConflict{PeerName: peerName, Network: subnet,
+					Kind: ConflictPeer, Owner: peerName, Conflicts: other})
+			}
+		}
+	}
+	return nil
+}
+
+// FindSubnetConflicts 查找数据库中已经存在的子网冲突，每一对重叠的子网只返回一次
+func FindSubnetConflicts(db *gorm.DB, denied []net.IPNet) ([]SubnetConflict, error) {
+	owned, err := ownedNetworks(db, denied)
+	if err != nil {
+		return nil, err
+	}
+	var conflicts []SubnetConflict
+	for i, subnet := range owned {
+		if subnet.kind != ConflictPeer {
+			continue
+		}
+		for j, other := range owned {
+			// 节点之间的重叠只在前一个子网处记录一次
+			if other.kind == ConflictPeer && j <= i {
+				continue
+			}
+			if networksOverlap(subnet.network, other.network) {
+				conflicts = append(conflicts, SubnetConflict{PeerName: subnet.owner, Network: subnet.network,
+					Kind: other.kind, Owner: other.owner, Conflicts: other.network})
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// ownedNetworks 获取覆盖网络、禁止使用的网络、所有节点的子网以及分配给节点的子网
+func ownedNetworks(db *gorm.DB, denied []net.IPNet) ([]ownedNetwork, error) {
+	var owned []ownedNetwork
+	seen := make(map[string]bool)
+	add := func(kind ConflictKind, owner string, network net.IPNet) {
+		key := fmt.Sprintf("%s/%s/%s", kind, owner, network.String())
+		if network.IP == nil || seen[key] {
+			return
+		}
+		seen[key] = true
+		owned = append(owned, ownedNetwork{kind: kind, owner: owner, network: network})
+	}
+
+	var peers []Peer
+	if err := db.Order("id").Find(&peers).Error; err != nil {
+		return nil, err
+	}
+	for _, peer := range peers {
+		if peer.IsServer {
+			for _, network := range peer.GetNetworks() {
+				add(ConflictOverlay, "", network)
+			}
+		}
+	}
+	for _, network := range denied {
+		add(ConflictDenied, "", network)
+	}
+	for _, peer := range peers {
+		for _, network := range peer.PeerSubnetAddress.GetNetworks() {
+			add(ConflictPeer, peer.PeerName, network)
+		}
+	}
+	// 离线检查旧版本的数据库时可能还没有分配子网的表
+	if !db.Migrator().HasTable(&SubnetAllocation{}) {
+		return owned, nil
+	}
+	var allocations []SubnetAllocation
+	if err := db.Order("id").Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+	for _, allocation := range allocations {
+		add(ConflictPeer, allocation.PeerName, allocation.Network.GetNetwork())
+	}
+	return owned, nil
+}
+
+// networksOverlap 两个同一协议的网络是否重叠
+func networksOverlap(a, b net.IPNet) bool {
+	return inet.NewNetworkAddress(a).Overlaps(inet.NewNetworkAddress(b))
+}
+
+// subnetConflictError 子网冲突的错误
+func subnetConflictError(conflict SubnetConflict) error {
+	return fmt.Errorf("%w: %s", errs.WgSubnetConflictError, conflict.String())
+}
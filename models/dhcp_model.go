@@ -1,32 +1,58 @@
 package models
 
 import (
+	"crypto/sha256"
 	"errors"
+	"fmt"
 	"net"
 	"time"
 
 	"github.com/onesaltedseafish/go-utils/simulate/dhcp"
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
 	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/samber/lo"
 	"gorm.io/gorm"
 )
 
 // DhcpClient models for DHCP Client
 type DhcpClient struct {
 	gorm.Model
-	CIDR         inet.CidrAddress `gorm:"column:cidr"`    // 分配的CIDR地址
-	Fqdn         string           `gorm:"column:fqdn"`    // 完整可用的域名,这里可以传递 nodename 进来
-	Address      inet.IpAddress   `gorm:"column:address"` // 分配的地址
-	HardwareAddr net.HardwareAddr `gorm:"column:mac"`     // MAC 地址
-	Enable       bool             `gorm:"column:enable"`  // 是否启用
+	CIDR         inet.CidrAddress `gorm:"column:cidr;uniqueIndex:idx_dhcp_address"`    // 分配的CIDR地址
+	Fqdn         string           `gorm:"column:fqdn"`                                 // 完整可用的域名,这里可以传递 nodename 进来
+	Address      inet.IpAddress   `gorm:"column:address;uniqueIndex:idx_dhcp_address"` // 分配的地址，同一个地址池中只有一条记录
+	HardwareAddr net.HardwareAddr `gorm:"column:mac"`                                  // MAC 地址
+	Enable       bool             `gorm:"column:enable"`                               // 是否启用
 	EnableTime   time.Time        // 启用的时间
+	ExpireTime   *time.Time       // 租约到期的时间，为空时不会过期
+}
+
+// AddressCursor 记录 GetLastAddress 上一次查找到的位置
+type AddressCursor struct {
+	ID      uint             `gorm:"primarykey"`
+	CIDR    inet.CidrAddress `gorm:"column:cidr;uniqueIndex"` // 地址池所在的网络
+	First   inet.IpAddress   `gorm:"column:first"`            // 查找时地址池的第一个地址
+	Address inet.IpAddress   `gorm:"column:address"`          // 从第一个地址开始连续分配过的最后一个地址
+}
+
+// PeerHardwareAddr 根据节点名生成一个本地管理的 MAC 地址
+// wg 节点没有真正的 MAC 地址，DHCP 分配时使用这个地址来标识节点
+func PeerHardwareAddr(peerName string) net.HardwareAddr {
+	sum := sha256.Sum256([]byte(peerName))
+	mac := net.HardwareAddr(sum[:6])
+	mac[0] = (mac[0] | 0x02) & 0xfe // 本地管理的单播地址
+	return mac
 }
 
 var _ dhcp.Storage = (*DhcpStorage)(nil)
 
+const allocateRetries = 5 // 地址被并发占用时最多重试的次数
+
 // DhcpStorage implements for dhcp.Storage
 type DhcpStorage struct {
-	db   *gorm.DB
-	cidr inet.CidrAddress
+	db    *gorm.DB
+	cidr  inet.CidrAddress
+	lease time.Duration // 地址的租约时长，不大于 0 时不会过期
+	pool  PoolConfig    // 可以分配的地址范围，为空时使用网络中所有的主机地址
 }
 
 // NewDHCPStorage New a DB implementation for dhcp.Storage
@@ -37,12 +63,20 @@ func NewDHCPStorage(db *gorm.DB, cidr inet.CidrAddress) *DhcpStorage {
 	}
 }
 
+// WithLease 返回使用该租约时长的地址池
+func (s *DhcpStorage) WithLease(lease time.Duration) *DhcpStorage {
+	storage := *s
+	storage.lease = lease
+	return &storage
+}
+
 // GetAddressWithMAC if storage has a record of hardwareAddr
 // then return the related ip address
 // else return nil
+// 之前使用的地址被预留给其他节点之后不再返回
 func (s *DhcpStorage) GetAddressWithMAC(mac net.HardwareAddr) (net.IP, error) {
-	record := DhcpClient{HardwareAddr: mac}
-	err := s.db.Model(DhcpClient{}).Where(&record).First(&record).Error
+	record := DhcpClient{CIDR: s.cidr, HardwareAddr: mac}
+	err := s.db.Model(DhcpClient{}).Where(&record).Where("address NOT IN (?)", s.reservedAddresses()).First(&record).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		err = nil
 	}
@@ -53,34 +87,61 @@ func (s *DhcpStorage) GetAddressWithMAC(mac net.HardwareAddr) (net.IP, error) {
 }
 
 // GetOneUnusedAddress finds the first unused record
+// 预留的地址以及不在地址池范围中的地址不会分配给其他节点
 func (s *DhcpStorage) GetOneUnusedAddress() (net.IP, error) {
-	var record DhcpClient
-	err := s.db.Model(DhcpClient{}).Where("enable = ? and cidr = ?", false, s.cidr).First(&record).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		err = nil
+	var records []DhcpClient
+	err := s.db.Model(DhcpClient{}).Where("enable = ? and cidr = ?", false, s.cidr).
+		Where("address NOT IN (?)", s.reservedAddresses()).Order("id").Find(&records).Error
+	if err != nil {
+		return nil, err
 	}
-	if record.ID != 0 {
-		return net.IP(record.Address), err
+	for _, record := range records {
+		if ip := net.IP(record.Address); s.inPool(ip) {
+			return ip, nil
+		}
 	}
-	return nil, err
+	return nil, nil
 }
 
 // GetLastAddress finds the last used ip address
+// 从地址池的第一个地址开始，返回第一个从来没有分配过的地址的前一个地址
+// 中继节点等预留的地址不在地址池的末尾时也不会影响后续的分配，IPv4 以及 IPv6 使用相同的计算方式
+// 地址的记录只会被禁用不会被删除，从上一次记录的位置继续查找，不需要读取地址池中所有的记录
 func (s *DhcpStorage) GetLastAddress() (net.IP, error) {
-	record := DhcpClient{
-		CIDR: s.cidr,
+	first := normalizeIP(s.firstAddress())
+	var cursor AddressCursor
+	if err := s.db.Where(&AddressCursor{CIDR: s.cidr}).Limit(1).Find(&cursor).Error; err != nil {
+		return nil, err
 	}
-	err := s.db.Model(DhcpClient{}).Where(&record).Last(&record).Error
-	if errors.Is(err, gorm.ErrRecordNotFound) {
-		err = nil
+	last := dhcp.IpAdd(first, -1)
+	// 地址池的范围修改之后从新的第一个地址开始查找
+	if cursor.ID != 0 && net.IP(cursor.First).Equal(first) {
+		last = normalizeIP(net.IP(cursor.Address))
 	}
-	if record.ID != 0 {
-		return net.IP(record.Address), err
+	start := last
+	for {
+		next := dhcp.IpAdd(last, 1)
+		var count int64
+		err := s.db.Model(DhcpClient{}).Where(&DhcpClient{CIDR: s.cidr, Address: inet.IpAddress(next)}).Count(&count).Error
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			break
+		}
+		last = next
+	}
+	if cursor.ID != 0 && net.IP(cursor.First).Equal(first) && last.Equal(start) {
+		return last, nil
 	}
-	return s.cidr.GetNetwork().IP, err
+	cursor.CIDR = s.cidr
+	cursor.First = inet.IpAddress(first)
+	cursor.Address = inet.IpAddress(last)
+	return last, s.db.Save(&cursor).Error
 }
 
 // SetAddressWithMAC sets record with ip address and MAC address
+// 地址同时被其他节点占用时返回 errs.WgAddressConflictError
 func (s *DhcpStorage) SetAddressWithMAC(ip net.IP, mac net.HardwareAddr) error {
 	record := DhcpClient{
 		CIDR:    s.cidr,
@@ -91,23 +152,50 @@ func (s *DhcpStorage) SetAddressWithMAC(ip net.IP, mac net.HardwareAddr) error {
 	err := tx.Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			// 新增
-			return s.db.Create(&DhcpClient{
+			// 新增，唯一索引保证同一个地址只会被新增一次
+			err = s.db.Create(&DhcpClient{
 				CIDR:         s.cidr,
 				Enable:       true,
 				HardwareAddr: mac,
 				Address:      inet.IpAddress(ip),
 				EnableTime:   time.Now(),
 			}).Error
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				err = fmt.Errorf("%w: %s", errs.WgAddressConflictError, ip.String())
+			}
 		}
 		return err
 	}
-	// 修改
-	return s.db.Model(&record).Updates(DhcpClient{
-		HardwareAddr: mac,
-		Enable:       true,
-		EnableTime:   time.Now(),
-	}).Error
+	// 修改，只能占用没有被使用或者已经属于该节点的地址，重新分配的地址需要重新续租
+	tx = s.db.Model(&record).Where("enable = ? OR mac = ?", false, mac).Updates(map[string]any{
+		"mac":         mac,
+		"enable":      true,
+		"enable_time": time.Now(),
+		"expire_time": nil,
+	})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", errs.WgAddressConflictError, ip.String())
+	}
+	return nil
+}
+
+// Transaction 在一个事务中执行地址的查找以及占用，地址被其他事务同时占用时重试
+func (s *DhcpStorage) Transaction(fn func(storage *DhcpStorage) error) error {
+	var err error
+	for i := 0; i < allocateRetries; i++ {
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			storage := *s
+			storage.db = tx
+			return fn(&storage)
+		})
+		if !errors.Is(err, errs.WgAddressConflictError) {
+			return err
+		}
+	}
+	return err
 }
 
 // ReleaseAddress release the address
@@ -126,7 +214,11 @@ func (s *DhcpStorage) ReleaseAddress(ip net.IP) error {
 }
 
 // IsUsed judge the ip address is used or not
+// 预留的地址同样视为已经被使用
 func (s *DhcpStorage) IsUsed(ip net.IP) (bool, error) {
+	if reserved, err := s.isReserved(ip); err != nil || reserved {
+		return reserved, err
+	}
 	record := DhcpClient{
 		CIDR:    s.cidr,
 		Address: inet.IpAddress(ip),
@@ -139,3 +231,30 @@ func (s *DhcpStorage) IsUsed(ip net.IP) (bool, error) {
 	}
 	return record.Enable, nil
 }
+
+// RenewAddress 按照地址池的租约时长续租地址，返回新的到期时间
+// 租约时长不大于 0 时地址不会过期，返回 nil
+func (s *DhcpStorage) RenewAddress(ip net.IP) (*time.Time, error) {
+	record, err := s.getRecord(ip)
+	if err != nil {
+		return nil, err
+	}
+	if !record.Enable {
+		return nil, fmt.Errorf("%w: %s", errs.WgLeaseNotFoundError, ip.String())
+	}
+	var expireTime *time.Time
+	if s.lease > 0 {
+		expireTime = lo.ToPtr(time.Now().Add(s.lease))
+	}
+	return expireTime, s.db.Model(&record).Update("expire_time", expireTime).Error
+}
+
+// GetExpiredAddresses 获取在 now 之前租约已经到期但是还没有释放的地址
+func (s *DhcpStorage) GetExpiredAddresses(now time.Time) ([]net.IP, error) {
+	var records []DhcpClient
+	err := s.db.Model(DhcpClient{}).Where(&DhcpClient{CIDR: s.cidr}).
+		Where("enable = ? AND expire_time IS NOT NULL AND expire_time < ?", true, now).Find(&records).Error
+	return lo.Map(records, func(item DhcpClient, _ int) net.IP {
+		return net.IP(item.Address)
+	}), err
+}
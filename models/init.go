@@ -1,6 +1,8 @@
 package models
 
 import (
+	"strings"
+
 	gormlog "github.com/onesaltedseafish/go-utils/log/gorm"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -12,7 +14,8 @@ var (
 
 // InitDb 初始化 DB
 func InitDb(dialector gorm.Dialector, migrate bool, logger *gormlog.Logger) (*gorm.DB, error) {
-	var config gorm.Config
+	// 将唯一索引冲突等错误转换为 gorm 的错误
+	config := gorm.Config{TranslateError: true}
 	var db *gorm.DB
 	var err error
 	if logger != nil {
@@ -23,7 +26,7 @@ func InitDb(dialector gorm.Dialector, migrate bool, logger *gormlog.Logger) (*go
 		return nil, err
 	}
 	if migrate {
-		err = db.AutoMigrate(Peer{})
+		err = db.AutoMigrate(Peer{}, DhcpClient{}, PeerRelay{}, AddressReservation{}, SubnetAllocation{}, AddressCursor{})
 		if err != nil {
 			return nil, err
 		}
@@ -33,8 +36,13 @@ func InitDb(dialector gorm.Dialector, migrate bool, logger *gormlog.Logger) (*go
 }
 
 // InitSqlite 初始化 sqlite
+// 事务开始时立即获取写锁并等待其他写事务结束，避免并发的写事务互相死锁
 func InitSqlite(sqlitePath string) gorm.Dialector {
-	return sqlite.Open(sqlitePath)
+	sep := "?"
+	if strings.Contains(sqlitePath, "?") {
+		sep = "&"
+	}
+	return sqlite.Open(sqlitePath + sep + "_txlock=immediate&_busy_timeout=30000")
 }
 
 // GetDb 获取全局 DB
@@ -2,6 +2,7 @@
 package models
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"time"
@@ -20,6 +21,7 @@ type Peer struct {
 	PublicIp          string               `gorm:"column:public_ip"`           // 公网 IP
 	PeerName          string               `gorm:"column:peer_name"`           // Peer 名称
 	PeerAddress       inet.CidrAddress     `gorm:"column:address"`             // Peer Ip 地址
+	PeerAddress6      inet.CidrAddress     `gorm:"column:address6"`            // Peer IPv6 地址，没有开启双栈时为空
 	PeerSubnetAddress inet.SubnetAddresses `gorm:"column:subnet_addresses"`    // 子网地址
 	PeerType          uint                 `gorm:"column:type"`                // 节点类型
 	IsServer          bool                 `gorm:"column:is_server"`           // 是否作为服务器
@@ -27,56 +29,186 @@ type Peer struct {
 	ListenPort        uint16               `gorm:"column:port"`                // 监听端口
 	PrivateKey        string               `gorm:"column:private_key"`         // 私钥
 	PublicKey         string               `gorm:"column:public_key"`          // 公钥
+	PresharedKey      string               `gorm:"column:preshared_key"`       // 与连接的节点之间的预共享密钥
 	KeepAliveInterval int                  `gorm:"column:keep_alive_interval"` // 保持心跳的时间间隔，单位秒
+	Mesh              bool                 `gorm:"column:mesh"`                // 是否与其他节点直接连接
+	Static            bool                 `gorm:"column:static"`              // 静态节点的地址租约不会过期
 	Remark            string               `gorm:"column:remark"`              // 备注
 }
 
+// GetPeerByName 根据节点名获取节点
+func GetPeerByName(db *gorm.DB, name string) (Peer, error) {
+	var peer Peer
+	err := db.Where("peer_name = ?", name).First(&peer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = fmt.Errorf("%w: %s", errs.WgPeerNotFoundError, name)
+	}
+	return peer, err
+}
+
+// GetPeerByPublicKey 根据公钥获取节点
+func GetPeerByPublicKey(db *gorm.DB, publicKey string) (Peer, error) {
+	var peer Peer
+	err := db.Where("public_key = ?", publicKey).First(&peer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = fmt.Errorf("%w: %s", errs.WgPeerNotFoundError, publicKey)
+	}
+	return peer, err
+}
+
+// GetRelayPeer 获取中继节点
+func GetRelayPeer(db *gorm.DB) (Peer, error) {
+	var relay Peer
+	err := db.Where(&Peer{IsServer: true}).First(&relay).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = errs.WgNoRelayPeerError
+	}
+	return relay, err
+}
+
 // ToWgServerConfig 将数据库中的record转换为 wg server peer初始化需要的记录
 func (p Peer) ToWgServerConfig() wg.WgServerConfig {
-	return wg.WgServerConfig{
+	config := wg.WgServerConfig{
 		InterfaceName: p.InterfaceName,
 		ListenPort:    int(p.ListenPort),
 		PrivateKey:    p.PrivateKey,
 		Address:       p.PeerAddress.ToNetlinkAddr(),
 	}
+	if !p.PeerAddress6.IsZero() {
+		address6 := p.PeerAddress6.ToNetlinkAddr()
+		config.Address6 = &address6
+	}
+	return config
 }
 
 // ToWgPeerConfig 将数据库中的 record 转换为 wg peer 初始化需要的记录
+// 返回当前使用的中继节点的配置
 func (p Peer) ToWgPeerConfig(db *gorm.DB) (wg.WgPeerConfig, error) {
 	var config wg.WgPeerConfig
-	var pubKey wgtypes.Key
-	var err error
-	var endpoint *net.UDPAddr
 	var allowIps []net.IPNet // 允许的子网
-	if p.ConnectTo == 0 {
-		return config, errs.WgNoConnectPeerError
+	connectPeer, err := p.GetConnectPeer(db)
+	if err != nil {
+		return config, err
 	}
-	connectPeer := Peer{}
-	connectPeer.ID = p.ConnectTo
-	if err = db.First(&connectPeer).Error; err != nil {
+	allowIps = append(allowIps, connectPeer.GetNetworks()...)
+	// 其他 SubNet 节点的子网地址都经过中继节点转发
+	subnets, err := p.GetRoutedSubnets(db)
+	if err != nil {
 		return config, err
 	}
-	if pubKey, err = wgtypes.ParseKey(connectPeer.PublicKey); err != nil {
+	allowIps = append(allowIps, subnets...)
+	return p.toWgRelayConfig(connectPeer, allowIps)
+}
+
+// toWgRelayConfig 节点上中继节点的配置，备用的中继节点没有 AllowedIPs
+func (p Peer) toWgRelayConfig(relay Peer, allowIps []net.IPNet) (wg.WgPeerConfig, error) {
+	var config wg.WgPeerConfig
+	pubKey, err := wgtypes.ParseKey(relay.PublicKey)
+	if err != nil {
 		return config, err
 	}
-	if endpoint, err = connectPeer.GetEndpoint(); err != nil {
+	endpoint, err := relay.GetEndpoint()
+	if err != nil {
 		return config, err
 	}
-	allowIps = append(allowIps, connectPeer.PeerAddress.GetNetwork())
-	if p.PeerType == uint(pb.PeerType_SubNet) {
-		allowIps = append(allowIps, p.PeerSubnetAddress.GetNetworks()...)
+	psk, err := p.GetPresharedKey()
+	if err != nil {
+		return config, err
 	}
 	return wg.WgPeerConfig{
 		InterfaceName: p.InterfaceName,
 		PeerConfig: wgtypes.PeerConfig{
 			PublicKey:                   pubKey,
+			PresharedKey:                psk,
 			Endpoint:                    endpoint,
 			PersistentKeepaliveInterval: p.GetKeepAliveInterval(),
+			ReplaceAllowedIPs:           true,
 			AllowedIPs:                  allowIps,
 		},
 	}, nil
 }
 
+// ToWgRelayPeerConfig 将数据库中的 record 转换为中继节点的 wg 设备上对应该节点的记录
+// 与 ToWgPeerConfig 相对应，SubNet 类型的节点还需要路由它的子网地址
+// 节点当前没有使用该中继节点时作为备用，没有 AllowedIPs
+func (p Peer) ToWgRelayPeerConfig(relay Peer) (wg.WgPeerConfig, error) {
+	var config wg.WgPeerConfig
+	pubKey, err := wgtypes.ParseKey(p.PublicKey)
+	if err != nil {
+		return config, err
+	}
+	psk, err := p.GetPresharedKey()
+	if err != nil {
+		return config, err
+	}
+	var allowIps []net.IPNet
+	if p.ConnectTo == relay.ID {
+		allowIps = append(allowIps, p.GetHostNetworks()...)
+		if p.PeerType == uint(pb.PeerType_SubNet) {
+			allowIps = append(allowIps, p.PeerSubnetAddress.GetNetworks()...)
+		}
+	}
+	config = wg.WgPeerConfig{
+		InterfaceName: relay.InterfaceName,
+		PeerConfig: wgtypes.PeerConfig{
+			PublicKey:                   pubKey,
+			PresharedKey:                psk,
+			PersistentKeepaliveInterval: p.GetKeepAliveInterval(),
+			ReplaceAllowedIPs:           true,
+			AllowedIPs:                  allowIps,
+		},
+	}
+	// 有公网地址的节点由中继节点主动连接
+	if endpoint, err := p.GetEndpoint(); err == nil {
+		config.PeerConfig.Endpoint = endpoint
+	}
+	return config, nil
+}
+
+// GetRoutedSubnets 获取需要经过该节点 wg 接口转发的子网地址
+// 除了自己以外所有 SubNet 节点的子网都经过 wg 接口转发，中继节点之间会互相转发各自节点的子网
+func (p Peer) GetRoutedSubnets(db *gorm.DB) ([]net.IPNet, error) {
+	var subnets []net.IPNet
+	var subnetPeers []Peer
+	if err := db.Where("type = ? AND is_server = ? AND id <> ?", uint(pb.PeerType_SubNet), false, p.ID).
+		Find(&subnetPeers).Error; err != nil {
+		return subnets, err
+	}
+	for _, subnetPeer := range subnetPeers {
+		subnets = append(subnets, subnetPeer.PeerSubnetAddress.GetNetworks()...)
+	}
+	return subnets, nil
+}
+
+// GetNetworks 获取节点地址所在的网络，双栈时同时包含 IPv4 以及 IPv6 的网络
+func (p Peer) GetNetworks() []net.IPNet {
+	networks := []net.IPNet{p.PeerAddress.GetNetwork()}
+	if !p.PeerAddress6.IsZero() {
+		networks = append(networks, p.PeerAddress6.GetNetwork())
+	}
+	return networks
+}
+
+// GetHostNetworks 获取只包含节点地址的网络，双栈时同时包含 IPv4 以及 IPv6 的地址
+func (p Peer) GetHostNetworks() []net.IPNet {
+	networks := []net.IPNet{p.PeerAddress.GetHostNetwork()}
+	if !p.PeerAddress6.IsZero() {
+		networks = append(networks, p.PeerAddress6.GetHostNetwork())
+	}
+	return networks
+}
+
+// GetConnectPeer 获取连接到的节点
+func (p Peer) GetConnectPeer(db *gorm.DB) (Peer, error) {
+	connectPeer := Peer{}
+	if p.ConnectTo == 0 {
+		return connectPeer, errs.WgNoConnectPeerError
+	}
+	connectPeer.ID = p.ConnectTo
+	err := db.First(&connectPeer).Error
+	return connectPeer, err
+}
+
 // GetEndpoint 获取连接端点
 func (p Peer) GetEndpoint() (addr *net.UDPAddr, err error) {
 	if p.ListenPort == 0 {
@@ -105,3 +237,15 @@ func (p Peer) GetKeepAliveInterval() *time.Duration {
 	dur = time.Second * time.Duration(p.KeepAliveInterval)
 	return &dur
 }
+
+// GetPresharedKey 获取预共享密钥，没有使用时返回 nil
+func (p Peer) GetPresharedKey() (*wgtypes.Key, error) {
+	if p.PresharedKey == "" {
+		return nil, nil
+	}
+	key, err := wgtypes.ParseKey(p.PresharedKey)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
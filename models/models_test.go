@@ -2,21 +2,26 @@ package models_test
 
 import (
 	"context"
+	"fmt"
+	"math"
 	"net"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/onesaltedseafish/go-utils/log"
 	gormlog "github.com/onesaltedseafish/go-utils/log/gorm"
 	"github.com/onesaltedseafish/go-utils/simulate/dhcp"
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
 	"github.com/onesaltedseafish/wg-tool/commons/inet"
 	"github.com/onesaltedseafish/wg-tool/commons/wg"
 	"github.com/onesaltedseafish/wg-tool/models"
 	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
@@ -35,8 +40,9 @@ var (
 
 func init() {
 	testDb, err = gorm.Open(
-		sqlite.Open(testSqlitePath), &gorm.Config{
-			Logger: gormLogger,
+		models.InitSqlite(testSqlitePath), &gorm.Config{
+			Logger:         gormLogger,
+			TranslateError: true,
 		},
 	)
 	if err != nil {
@@ -46,6 +52,9 @@ func init() {
 	if err = testDb.AutoMigrate(
 		models.Peer{},
 		models.DhcpClient{},
+		models.AddressReservation{},
+		models.SubnetAllocation{},
+		models.AddressCursor{},
 	); err != nil {
 		logger.Fatal(ctx, "migrate models error", zap.Error(err))
 	}
@@ -159,8 +168,9 @@ func TestDHCPDBImpl(t *testing.T) {
 	dhcpClient := dhcp.New(cidr.GetNetwork(), models.NewDHCPStorage(testDb, cidr))
 
 	// 删除表中所有存在的记录
-	err = testDb.Where("1 = 1").Delete(&models.DhcpClient{}).Error
+	err = testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error
 	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
 
 	// allocate from start
 	ip, err = dhcpClient.AllocateAddress(mac1)
@@ -188,3 +198,548 @@ func TestDHCPDBImpl(t *testing.T) {
 	_, err = dhcpClient.AllocateAddress(mac5)
 	assert.NotEqual(t, nil, err)
 }
+
+func TestDHCPDBImplIPv6(t *testing.T) {
+	var (
+		cidr, err = inet.NewCidrAddressFromString("fd00:222::1/126")
+		mac1, _   = net.ParseMAC("00:16:3e:03:57:45")
+		mac2, _   = net.ParseMAC("02:42:be:7f:b3:58")
+		mac3, _   = net.ParseMAC("02:42:fe:21:ad:e3")
+	)
+	assert.Equal(t, nil, err)
+	err = testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+
+	storage := models.NewDHCPStorage(testDb, cidr)
+	dhcpClient := dhcp.New(cidr.GetNetwork(), storage)
+	// 中继节点的地址已经被使用
+	assert.Equal(t, nil, storage.SetAddressWithMAC(cidr.GetAddress(), mac1))
+	ip, err := dhcpClient.AllocateAddress(mac2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00:222::2", ip.String())
+	ip, err = dhcpClient.AllocateAddress(mac3)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00:222::3", ip.String())
+	// 同一个 MAC 地址分配同样的地址
+	ip, err = dhcpClient.AllocateAddress(mac2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00:222::2", ip.String())
+}
+
+func TestDhcpLease(t *testing.T) {
+	var (
+		cidr, _ = inet.NewCidrAddressFromString("192.168.0.1/24")
+		mac, _  = net.ParseMAC("00:16:3e:03:57:45")
+		ip      = net.ParseIP("192.168.0.2")
+		storage = models.NewDHCPStorage(testDb, cidr)
+	)
+	err := testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+
+	assert.Equal(t, nil, storage.SetAddressWithMAC(ip, mac))
+	// 没有租约时长时不会过期
+	expireTime, err := storage.RenewAddress(ip)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*time.Time)(nil), expireTime)
+	expireTime, err = storage.WithLease(time.Hour).RenewAddress(ip)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, expireTime.After(time.Now()))
+	expired, err := storage.GetExpiredAddresses(time.Now())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(expired))
+	expired, err = storage.GetExpiredAddresses(time.Now().Add(2 * time.Hour))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"192.168.0.2"}, lo.Map(expired, func(item net.IP, _ int) string {
+		return item.String()
+	}))
+
+	// 释放之后不能续租，重新分配之后需要重新续租
+	assert.Equal(t, nil, storage.ReleaseAddress(ip))
+	_, err = storage.RenewAddress(ip)
+	assert.ErrorIs(t, err, errs.WgLeaseNotFoundError)
+	assert.Equal(t, nil, storage.SetAddressWithMAC(ip, mac))
+	expired, err = storage.GetExpiredAddresses(time.Now().Add(2 * time.Hour))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(expired))
+}
+
+func TestLastAddress(t *testing.T) {
+	var (
+		cidr, _ = inet.NewCidrAddressFromString("10.8.0.1/24")
+		mac, _  = net.ParseMAC("00:16:3e:03:57:45")
+		storage = models.NewDHCPStorage(testDb, cidr)
+	)
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+	lastAddress := func(storage *models.DhcpStorage) string {
+		last, err := storage.GetLastAddress()
+		assert.Equal(t, nil, err)
+		return last.String()
+	}
+
+	assert.Equal(t, "10.8.0.0", lastAddress(storage))
+	for _, ip := range []string{"10.8.0.1", "10.8.0.2", "10.8.0.5"} {
+		assert.Equal(t, nil, storage.SetAddressWithMAC(net.ParseIP(ip), mac))
+	}
+	assert.Equal(t, "10.8.0.2", lastAddress(storage))
+	// 从记录的位置继续查找，释放的地址仍然视为分配过
+	assert.Equal(t, nil, storage.ReleaseAddress(net.ParseIP("10.8.0.2")))
+	assert.Equal(t, nil, storage.SetAddressWithMAC(net.ParseIP("10.8.0.3"), mac))
+	assert.Equal(t, nil, storage.SetAddressWithMAC(net.ParseIP("10.8.0.4"), mac))
+	assert.Equal(t, "10.8.0.5", lastAddress(storage))
+	var cursors []models.AddressCursor
+	assert.Equal(t, nil, testDb.Find(&cursors).Error)
+	assert.Equal(t, 1, len(cursors))
+	assert.Equal(t, "10.8.0.5", cursors[0].Address.String())
+
+	// 地址池的范围修改之后从新的第一个地址开始查找
+	pool, err := models.ParsePoolConfig("10.8.0.100", "", nil)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.8.0.99", lastAddress(storage.WithPool(pool)))
+	assert.Equal(t, "10.8.0.5", lastAddress(storage))
+}
+
+func TestAddressReservation(t *testing.T) {
+	var (
+		cidr, _ = inet.NewCidrAddressFromString("10.0.0.1/29")
+		mac1, _ = net.ParseMAC("00:16:3e:03:57:45")
+		mac2, _ = net.ParseMAC("02:42:be:7f:b3:58")
+		storage = models.NewDHCPStorage(testDb, cidr)
+		client  = dhcp.New(cidr.GetNetwork(), storage)
+	)
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressReservation{}).Error)
+
+	_, err := storage.AddReservation(models.AddressReservation{PeerName: "router", Address: inet.IpAddress(net.ParseIP("10.0.0.1"))})
+	assert.Equal(t, nil, err)
+	_, err = storage.AddReservation(models.AddressReservation{HardwareAddr: mac2, Address: inet.IpAddress(net.ParseIP("10.0.0.3"))})
+	assert.Equal(t, nil, err)
+	// 同一个地址或者同一个节点只能预留一次，地址必须在地址池中
+	_, err = storage.AddReservation(models.AddressReservation{PeerName: "monitor", Address: inet.IpAddress(net.ParseIP("10.0.0.1"))})
+	assert.ErrorIs(t, err, errs.WgReservationExistsError)
+	_, err = storage.AddReservation(models.AddressReservation{PeerName: "router", Address: inet.IpAddress(net.ParseIP("10.0.0.4"))})
+	assert.ErrorIs(t, err, errs.WgReservationExistsError)
+	_, err = storage.AddReservation(models.AddressReservation{PeerName: "monitor", Address: inet.IpAddress(net.ParseIP("10.0.1.1"))})
+	assert.ErrorIs(t, err, errs.WgInvalidAddressError)
+
+	reservation, err := storage.GetReservation("node", "", mac2)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.0.0.3", reservation.Address.String())
+	reservation, err = storage.GetReservation("node", "", mac1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*models.AddressReservation)(nil), reservation)
+
+	// 按顺序分配时跳过预留的地址
+	ip, err := client.AllocateAddress(mac1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.0.0.2", ip.String())
+	ip, err = client.AllocateAddress(models.PeerHardwareAddr("node"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.0.0.4", ip.String())
+	// 之前使用的地址被预留之后不会再分配给该节点
+	assert.Equal(t, nil, storage.ReleaseAddress(net.ParseIP("10.0.0.2")))
+	_, err = storage.AddReservation(models.AddressReservation{PeerName: "monitor", Address: inet.IpAddress(net.ParseIP("10.0.0.2"))})
+	assert.Equal(t, nil, err)
+	ip, err = storage.GetOneUnusedAddress()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, net.IP(nil), ip)
+	ip, err = client.AllocateAddress(mac1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.0.0.5", ip.String())
+
+	// 已经被使用的地址不能预留给其他节点
+	_, err = storage.AddReservation(models.AddressReservation{PeerName: "other", Address: inet.IpAddress(net.ParseIP("10.0.0.5"))})
+	assert.ErrorIs(t, err, errs.WgInvalidAddressError)
+	assert.Equal(t, nil, storage.DeleteReservation(net.ParseIP("10.0.0.2")))
+	assert.ErrorIs(t, storage.DeleteReservation(net.ParseIP("10.0.0.2")), errs.WgReservationNotFoundError)
+}
+
+func TestAddressAllocator(t *testing.T) {
+	var (
+		cidr, _  = inet.NewCidrAddressFromString("fd00:222::1/64")
+		mac1, _  = net.ParseMAC("00:16:3e:03:57:45")
+		mac2, _  = net.ParseMAC("02:42:be:7f:b3:58")
+		_, key1  = lo.Must2(wg.GenerateWgKeyPairs())
+		_, key2  = lo.Must2(wg.GenerateWgKeyPairs())
+		network  = cidr.GetNetwork()
+		storage  = models.NewDHCPStorage(testDb, cidr)
+		cidr4, _ = inet.NewCidrAddressFromString("192.168.0.1/24")
+	)
+	err := testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+
+	_, err = models.ParseAllocateStrategy("unknown")
+	assert.ErrorIs(t, err, errs.WgInvalidStrategyError)
+	// 根据公钥生成地址只能用于 IPv6 网络
+	_, err = models.NewAddressAllocator(models.StrategyKeyHash, models.NewDHCPStorage(testDb, cidr4))
+	assert.ErrorIs(t, err, errs.WgInvalidStrategyError)
+
+	allocator, err := models.NewAddressAllocator(models.StrategyKeyHash, storage)
+	assert.Equal(t, nil, err)
+	ip1, err := allocator.AllocateAddress(mac1, key1.String())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, network.Contains(ip1))
+	// 释放之后同样的公钥得到同样的地址
+	assert.Equal(t, nil, storage.ReleaseAddress(ip1))
+	ip, err := allocator.AllocateAddress(mac1, key1.String())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, ip1.String(), ip.String())
+	ip2, err := allocator.AllocateAddress(mac2, key2.String())
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, ip1.String(), ip2.String())
+	// 地址被其他节点使用时解决哈希冲突
+	assert.Equal(t, nil, storage.ReleaseAddress(ip1))
+	assert.Equal(t, nil, storage.SetAddressWithMAC(ip1, mac2))
+	ip, err = allocator.AllocateAddress(mac1, key1.String())
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, ip1.String(), ip.String())
+	assert.Equal(t, true, network.Contains(ip))
+	_, err = allocator.AllocateAddress(mac1, "invalid")
+	assert.ErrorIs(t, err, errs.WgInvalidKeyError)
+
+	// 随机分配时同一个节点得到同样的地址，不会分配网络地址以及广播地址
+	storage = models.NewDHCPStorage(testDb, cidr4)
+	allocator, err = models.NewAddressAllocator(models.StrategyRandom, storage)
+	assert.Equal(t, nil, err)
+	allocated := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		mac := models.PeerHardwareAddr(fmt.Sprintf("node%d", i))
+		ip, err = allocator.AllocateAddress(mac, "")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, allocated[ip.String()])
+		assert.NotEqual(t, "192.168.0.0", ip.String())
+		assert.NotEqual(t, "192.168.0.255", ip.String())
+		allocated[ip.String()] = true
+		again, err := allocator.AllocateAddress(mac, "")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, ip.String(), again.String())
+	}
+}
+
+func TestAddressPool(t *testing.T) {
+	var (
+		cidr, _  = inet.NewCidrAddressFromString("192.168.0.1/24")
+		cidr6, _ = inet.NewCidrAddressFromString("fd00:222::1/64")
+		mac, _   = net.ParseMAC("00:16:3e:03:57:45")
+	)
+	err := testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+	err = testDb.Where("1 = 1").Delete(&models.AddressReservation{}).Error
+	assert.Equal(t, nil, err)
+
+	_, err = models.ParseIpRange("192.168.0.20-192.168.0.10")
+	assert.ErrorIs(t, err, errs.WgInvalidPoolError)
+	_, err = models.ParseIpRange("192.168.0.10-fd00::1")
+	assert.ErrorIs(t, err, errs.WgInvalidPoolError)
+	_, err = models.ParsePoolConfig("invalid", "", nil)
+	assert.ErrorIs(t, err, errs.WgInvalidPoolError)
+	// 开始以及结束的地址必须是网络中的主机地址
+	for _, c := range [][2]string{{"192.168.0.0", ""}, {"", "192.168.0.255"}, {"192.168.1.1", ""}, {"192.168.0.20", "192.168.0.10"}} {
+		pool, err := models.ParsePoolConfig(c[0], c[1], nil)
+		assert.Equal(t, nil, err)
+		assert.ErrorIs(t, pool.Check(cidr.GetNetwork()), errs.WgInvalidPoolError, c)
+	}
+	pool, err := models.ParsePoolConfig("", "", []string{"192.168.1.1"})
+	assert.Equal(t, nil, err)
+	assert.ErrorIs(t, pool.Check(cidr.GetNetwork()), errs.WgInvalidPoolError)
+
+	// 默认不会分配网络地址以及广播地址
+	storage := models.NewDHCPStorage(testDb, cidr)
+	stats, err := storage.Stats()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.0.1", stats.First.String())
+	assert.Equal(t, "192.168.0.254", stats.Last.String())
+	assert.Equal(t, uint64(254), stats.Total)
+	assert.Equal(t, uint64(254), stats.Free)
+
+	// 只在 .10-.20 中分配，跳过排除的地址
+	pool, err = models.ParsePoolConfig("192.168.0.10", "192.168.0.20",
+		[]string{"192.168.0.12-192.168.0.14", "192.168.0.13-192.168.0.15", "192.168.0.18", "192.168.0.100"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, pool.Check(cidr.GetNetwork()))
+	storage = storage.WithPool(pool)
+	_, err = storage.AddReservation(models.AddressReservation{Address: inet.IpAddress(net.ParseIP("192.168.0.19")), PeerName: "node"})
+	assert.Equal(t, nil, err)
+	allocator, err := models.NewAddressAllocator(models.StrategySequential, storage)
+	assert.Equal(t, nil, err)
+	var allocated []string
+	for i := 0; ; i++ {
+		ip, err := allocator.AllocateAddress(models.PeerHardwareAddr(fmt.Sprintf("node%d", i)), "")
+		if err != nil {
+			assert.ErrorIs(t, err, errs.WgPoolExhaustedError)
+			break
+		}
+		allocated = append(allocated, ip.String())
+	}
+	assert.Equal(t, []string{"192.168.0.10", "192.168.0.11", "192.168.0.16", "192.168.0.17", "192.168.0.20"}, allocated)
+	stats, err = storage.Stats()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(6), stats.Total)
+	assert.Equal(t, uint64(5), stats.Used)
+	assert.Equal(t, uint64(1), stats.Reserved)
+	assert.Equal(t, uint64(0), stats.Free)
+
+	// 释放的地址被排除之后不再分配
+	assert.Equal(t, nil, storage.ReleaseAddress(net.ParseIP("192.168.0.16")))
+	pool.Exclude = append(pool.Exclude, models.IpRange{Start: net.ParseIP("192.168.0.16"), End: net.ParseIP("192.168.0.16")})
+	allocator, _ = models.NewAddressAllocator(models.StrategySequential, storage.WithPool(pool))
+	_, err = allocator.AllocateAddress(mac, "")
+	assert.ErrorIs(t, err, errs.WgPoolExhaustedError)
+	allocator, _ = models.NewAddressAllocator(models.StrategyRandom, storage)
+	ip, err := allocator.AllocateAddress(mac, "")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.0.16", ip.String())
+
+	// IPv6 地址池的数量超过 uint64 时使用最大值
+	storage = models.NewDHCPStorage(testDb, cidr6)
+	stats, err = storage.Stats()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(math.MaxUint64), stats.Total)
+	assert.Equal(t, "fd00:222::ffff:ffff:ffff:ffff", stats.Last.String())
+	pool, err = models.ParsePoolConfig("fd00:222::100", "fd00:222::1ff", nil)
+	assert.Equal(t, nil, err)
+	storage = storage.WithPool(pool)
+	allocator, _ = models.NewAddressAllocator(models.StrategyRandom, storage)
+	for i := 0; i < 10; i++ {
+		ip, err = allocator.AllocateAddress(models.PeerHardwareAddr(fmt.Sprintf("node%d", i)), "")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, models.IpRange{Start: pool.Start, End: pool.End}.Contains(ip), ip.String())
+	}
+	stats, err = storage.Stats()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(256), stats.Total)
+	assert.Equal(t, uint64(10), stats.Used)
+	assert.Equal(t, uint64(246), stats.Free)
+}
+
+func TestSubnetAllocation(t *testing.T) {
+	var (
+		_, supernet, _  = net.ParseCIDR("10.100.0.0/16")
+		_, supernet6, _ = net.ParseCIDR("fd00:100::/48")
+		_, small, _     = net.ParseCIDR("10.200.0.0/30")
+		subnets, _      = inet.NewSubnetAddressesFromString("10.100.0.1/23")
+	)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.SubnetAllocation{}).Error)
+	assert.Equal(t, nil, testDb.Unscoped().Where("peer_name = ?", "site0").Delete(&models.Peer{}).Error)
+	// 节点自己声明的子网不会被分配
+	assert.Equal(t, nil, testDb.Create(&models.Peer{PeerName: "site0", PeerSubnetAddress: subnets}).Error)
+
+	storage := models.NewSubnetStorage(testDb, *supernet, 24)
+	var allocated []string
+	for i, prefix := range []int{0, 25, 24, 23, 25} {
+		network, err := storage.AllocateSubnet(fmt.Sprintf("site%d", i+1), prefix)
+		assert.Equal(t, nil, err)
+		allocated = append(allocated, network.String())
+	}
+	assert.Equal(t, []string{"10.100.2.0/24", "10.100.3.0/25", "10.100.4.0/24", "10.100.6.0/23", "10.100.3.128/25"}, allocated)
+	_, err := storage.AllocateSubnet("site6", 8)
+	assert.ErrorIs(t, err, errs.WgInvalidPrefixError)
+	_, err = storage.AllocateSubnet("site6", 33)
+	assert.ErrorIs(t, err, errs.WgInvalidPrefixError)
+
+	// 释放之后可以重新分配
+	assert.Equal(t, nil, models.ReleasePeerSubnets(testDb, "site1"))
+	network, err := storage.AllocateSubnet("site6", 0)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.2.0/24", network.String())
+	allocations, err := storage.GetSubnetAllocations()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 5, len(allocations))
+
+	// 超网用完之后返回错误
+	storage = models.NewSubnetStorage(testDb, *small, 31)
+	for i := 0; i < 2; i++ {
+		_, err = storage.AllocateSubnet(fmt.Sprintf("small%d", i), 0)
+		assert.Equal(t, nil, err)
+	}
+	_, err = storage.AllocateSubnet("small2", 0)
+	assert.ErrorIs(t, err, errs.WgSubnetExhaustedError)
+
+	storage = models.NewSubnetStorage(testDb, *supernet6, 64)
+	for i, expected := range []string{"fd00:100::/64", "fd00:100:0:1::/64"} {
+		network, err = storage.AllocateSubnet(fmt.Sprintf("site%d", i+1), 0)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, expected, network.String())
+	}
+	assert.Equal(t, nil, testDb.Unscoped().Where("peer_name = ?", "site0").Delete(&models.Peer{}).Error)
+}
+
+func TestSubnetConflicts(t *testing.T) {
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.Peer{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.SubnetAllocation{}).Error)
+	newPeer := func(name, address, subnets string, isServer bool) {
+		peer := models.Peer{PeerName: name, IsServer: isServer}
+		peer.PeerAddress, _ = inet.NewCidrAddressFromString(address)
+		if subnets != "" {
+			peer.PeerSubnetAddress, _ = inet.NewSubnetAddressesFromString(subnets)
+		}
+		assert.Equal(t, nil, testDb.Create(&peer).Error)
+	}
+	newPeer("relay", "192.168.222.1/24", "", true)
+	newPeer("site1", "192.168.222.2/24", "10.0.0.1/24, 10.1.0.1/16", false)
+	_, lan, _ := net.ParseCIDR("192.168.1.0/24")
+	denied := []net.IPNet{*lan}
+	networks := func(cidrs ...string) []net.IPNet {
+		return lo.Map(cidrs, func(item string, _ int) net.IPNet {
+			_, network, _ := net.ParseCIDR(item)
+			return *network
+		})
+	}
+
+	assert.Equal(t, nil, models.CheckSubnetConflicts(testDb, "site2", networks("10.2.0.0/24", "fd00::/64"), denied))
+	// 节点修改子网时不会与自己原来的子网冲突
+	assert.Equal(t, nil, models.CheckSubnetConflicts(testDb, "site1", networks("10.0.0.0/25"), denied))
+	for cidr, message := range map[string]string{
+		"10.0.0.128/25":    "节点 site2 的子网 10.0.0.128/25 与节点 site1 的子网 10.0.0.0/24 重叠",
+		"10.0.0.0/8":       "节点 site2 的子网 10.0.0.0/8 与节点 site1 的子网 10.0.0.0/24 重叠",
+		"192.168.222.0/25": "节点 site2 的子网 192.168.222.0/25 与覆盖网络 192.168.222.0/24 重叠",
+		"192.168.0.0/16":   "节点 site2 的子网 192.168.0.0/16 与覆盖网络 192.168.222.0/24 重叠",
+		"192.168.1.128/25": "节点 site2 的子网 192.168.1.128/25 与禁止使用的网络 192.168.1.0/24 重叠",
+	} {
+		err := models.CheckSubnetConflicts(testDb, "site2", networks(cidr), denied)
+		assert.ErrorIs(t, err, errs.WgSubnetConflictError)
+		assert.Contains(t, err.Error(), message)
+	}
+	err := models.CheckSubnetConflicts(testDb, "site2", networks("10.2.0.0/24", "10.2.0.0/25"), denied)
+	assert.ErrorIs(t, err, errs.WgSubnetConflictError)
+
+	// 离线检查已经存在的冲突，每一对冲突只返回一次
+	conflicts, err := models.FindSubnetConflicts(testDb, denied)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(conflicts))
+	newPeer("site2", "192.168.222.3/24", "10.1.2.1/24, 192.168.1.1/24", false)
+	assert.Equal(t, nil, testDb.Create(&models.SubnetAllocation{
+		Network:  lo.Must(inet.NewCidrAddressFromString("10.0.0.0/16")),
+		PeerName: "site3",
+	}).Error)
+	conflicts, err = models.FindSubnetConflicts(testDb, denied)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{
+		"节点 site1 的子网 10.0.0.0/24 与节点 site3 的子网 10.0.0.0/16 重叠",
+		"节点 site1 的子网 10.1.0.0/16 与节点 site2 的子网 10.1.2.0/24 重叠",
+		"节点 site2 的子网 192.168.1.0/24 与禁止使用的网络 192.168.1.0/24 重叠",
+	}, lo.Map(conflicts, func(item models.SubnetConflict, _ int) string {
+		return item.String()
+	}))
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.Peer{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.SubnetAllocation{}).Error)
+}
+
+func TestConcurrentAllocation(t *testing.T) {
+	const count = 300
+	var (
+		cidr, _ = inet.NewCidrAddressFromString("10.1.0.1/16")
+		network = cidr.GetNetwork()
+		storage = models.NewDHCPStorage(testDb, cidr)
+		ips     = make([]net.IP, count)
+		results = make([]error, count)
+		wg      sync.WaitGroup
+	)
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			mac := models.PeerHardwareAddr(fmt.Sprintf("node%d", i))
+			results[i] = storage.Transaction(func(storage *models.DhcpStorage) error {
+				var err error
+				ips[i], err = dhcp.New(network, storage).AllocateAddress(mac)
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	allocated := make(map[string]bool)
+	for i := 0; i < count; i++ {
+		assert.Equal(t, nil, results[i])
+		assert.Equal(t, false, allocated[ips[i].String()], ips[i].String())
+		allocated[ips[i].String()] = true
+	}
+	var records int64
+	assert.Equal(t, nil, testDb.Model(&models.DhcpClient{}).Where("enable = ?", true).Count(&records).Error)
+	assert.Equal(t, int64(count), records)
+	// 已经被使用的地址不能被其他节点占用
+	assert.ErrorIs(t, storage.SetAddressWithMAC(ips[0], models.PeerHardwareAddr("other")), errs.WgAddressConflictError)
+}
+
+func TestPeerWgConfigs(t *testing.T) {
+	relayAddress, _ := inet.NewCidrAddressFromString("192.168.223.1/24")
+	_, relayPubKey, _ := wg.GenerateWgKeyPairs()
+	relay := models.Peer{
+		InterfaceName: "wg1",
+		PeerName:      "relay_node2",
+		PeerAddress:   relayAddress,
+		IsServer:      true,
+		ListenPort:    51820,
+		PublicKey:     relayPubKey.String(),
+		PublicIp:      "1.2.3.4",
+	}
+	assert.Equal(t, nil, testDb.Create(&relay).Error)
+
+	newPeer := func(name, address, subnets string) models.Peer {
+		peerAddress, _ := inet.NewCidrAddressFromString(address)
+		_, pubKey, _ := wg.GenerateWgKeyPairs()
+		peer := models.Peer{
+			InterfaceName: "wg1",
+			PeerName:      name,
+			PeerAddress:   peerAddress,
+			PeerType:      uint(pb.PeerType_P2P),
+			ConnectTo:     relay.ID,
+			PublicKey:     pubKey.String(),
+		}
+		if subnets != "" {
+			peer.PeerType = uint(pb.PeerType_SubNet)
+			peer.PeerSubnetAddress, _ = inet.NewSubnetAddressesFromString(subnets)
+		}
+		assert.Equal(t, nil, testDb.Create(&peer).Error)
+		return peer
+	}
+	site1 := newPeer("site1", "192.168.223.2/24", "10.1.0.0/24")
+	site2 := newPeer("site2", "192.168.223.3/24", "10.2.0.0/24, 10.3.0.0/24")
+	laptop := newPeer("laptop", "192.168.223.4/24", "")
+
+	testcases := []struct {
+		Peer        models.Peer
+		WantRelay   string // 中继节点上该节点的 AllowedIPs
+		WantConnect string // 该节点上中继节点的 AllowedIPs
+	}{
+		{site1, "192.168.223.2/32,10.1.0.0/24", "192.168.223.0/24,10.2.0.0/24,10.3.0.0/24"},
+		{site2, "192.168.223.3/32,10.2.0.0/24,10.3.0.0/24", "192.168.223.0/24,10.1.0.0/24"},
+		{laptop, "192.168.223.4/32", "192.168.223.0/24,10.1.0.0/24,10.2.0.0/24,10.3.0.0/24"},
+	}
+	for _, testcase := range testcases {
+		relayConfig, err := testcase.Peer.ToWgRelayPeerConfig(relay)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "wg1", relayConfig.InterfaceName)
+		assert.Equal(t, testcase.Peer.PublicKey, relayConfig.PeerConfig.PublicKey.String())
+		assert.Equal(t, testcase.WantRelay, networksString(relayConfig.PeerConfig.AllowedIPs))
+
+		peerConfig, err := testcase.Peer.ToWgPeerConfig(testDb)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, relay.PublicKey, peerConfig.PeerConfig.PublicKey.String())
+		assert.Equal(t, "1.2.3.4:51820", peerConfig.PeerConfig.Endpoint.String())
+		assert.Equal(t, testcase.WantConnect, networksString(peerConfig.PeerConfig.AllowedIPs))
+	}
+
+	// 删除
+	for _, v := range []models.Peer{relay, site1, site2, laptop} {
+		assert.Equal(t, nil, testDb.Delete(&v).Error)
+	}
+}
+
+// networksString 将网络地址转换为以,分隔的字符串
+func networksString(networks []net.IPNet) string {
+	var result []string
+	for _, network := range networks {
+		result = append(result, network.String())
+	}
+	return strings.Join(result, ",")
+}
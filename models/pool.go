@@ -0,0 +1,279 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/onesaltedseafish/go-utils/simulate/dhcp"
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+)
+
+// IpRange 地址范围，包括开始以及结束的地址
+type IpRange struct {
+	Start net.IP
+	End   net.IP
+}
+
+// ParseIpRange 解析 "192.168.222.100" 或者 "192.168.222.100-192.168.222.150" 形式的地址范围
+func ParseIpRange(s string) (IpRange, error) {
+	start, end, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		end = start
+	}
+	r := IpRange{
+		Start: normalizeIP(net.ParseIP(strings.TrimSpace(start))),
+		End:   normalizeIP(net.ParseIP(strings.TrimSpace(end))),
+	}
+	if r.Start == nil || r.End == nil || len(r.Start) != len(r.End) || compareIP(r.Start, r.End) > 0 {
+		return IpRange{}, fmt.Errorf("%w: 地址范围 %s", errs.WgInvalidPoolError, s)
+	}
+	return r, nil
+}
+
+// Contains 地址是否在范围中
+func (r IpRange) Contains(ip net.IP) bool {
+	ip, start := normalizeIP(ip), normalizeIP(r.Start)
+	return len(ip) == len(start) && compareIP(start, ip) <= 0 && compareIP(ip, r.End) <= 0
+}
+
+// String 返回 "开始-结束" 形式的地址范围，只有一个地址时只返回该地址
+func (r IpRange) String() string {
+	if r.Start.Equal(r.End) {
+		return r.Start.String()
+	}
+	return r.Start.String() + "-" + r.End.String()
+}
+
+// PoolConfig 地址池中可以分配的地址范围，没有指定开始或者结束的地址时使用网络中所有的主机地址
+type PoolConfig struct {
+	Start   net.IP    // 第一个可以分配的地址，为空时使用网络地址的下一个地址
+	End     net.IP    // 最后一个可以分配的地址，为空时使用网络中最后一个地址，IPv4 不包括广播地址
+	Exclude []IpRange // 不分配的地址，例如网关以及留作他用的地址
+}
+
+// ParsePoolConfig 解析地址池的配置，start 以及 end 可以为空
+func ParsePoolConfig(start, end string, exclude []string) (PoolConfig, error) {
+	var config PoolConfig
+	for _, item := range []struct {
+		s  string
+		ip *net.IP
+	}{{start, &config.Start}, {end, &config.End}} {
+		if item.s == "" {
+			continue
+		}
+		if *item.ip = normalizeIP(net.ParseIP(item.s)); *item.ip == nil {
+			return PoolConfig{}, fmt.Errorf("%w: %s", errs.WgInvalidPoolError, item.s)
+		}
+	}
+	for _, s := range exclude {
+		r, err := ParseIpRange(s)
+		if err != nil {
+			return PoolConfig{}, err
+		}
+		config.Exclude = append(config.Exclude, r)
+	}
+	return config, nil
+}
+
+// Check 检查地址池的配置是否可以用于该网络
+// 开始以及结束的地址必须是网络中的主机地址，排除的地址必须在网络中
+func (c PoolConfig) Check(network net.IPNet) error {
+	hosts := IpRange{Start: poolFirst(network, PoolConfig{}), End: poolLast(network, PoolConfig{})}
+	for _, ip := range []net.IP{c.Start, c.End} {
+		if ip != nil && !hosts.Contains(ip) {
+			return fmt.Errorf("%w: %s 不是 %s 中的主机地址", errs.WgInvalidPoolError, ip.String(), network.String())
+		}
+	}
+	if first, last := poolFirst(network, c), poolLast(network, c); compareIP(first, last) > 0 {
+		return fmt.Errorf("%w: 开始地址 %s 大于结束地址 %s", errs.WgInvalidPoolError, first.String(), last.String())
+	}
+	for _, r := range c.Exclude {
+		if !network.Contains(r.Start) || !network.Contains(r.End) {
+			return fmt.Errorf("%w: %s 不在 %s 中", errs.WgInvalidPoolError, r.String(), network.String())
+		}
+	}
+	return nil
+}
+
+// PoolStats 地址池的使用情况，地址数量超过 uint64 时为 math.MaxUint64
+type PoolStats struct {
+	Network  net.IPNet // 地址池所在的网络
+	First    net.IP    // 第一个可以分配的地址
+	Last     net.IP    // 最后一个可以分配的地址
+	Total    uint64    // 可以分配的地址数量，不包括排除的地址
+	Used     uint64    // 已经分配给节点的地址数量
+	Reserved uint64    // 预留并且还没有被使用的地址数量
+	Free     uint64    // 还可以分配给其他节点的地址数量
+}
+
+// WithPool 返回只在 pool 范围中分配地址的地址池
+func (s *DhcpStorage) WithPool(pool PoolConfig) *DhcpStorage {
+	storage := *s
+	storage.pool = pool
+	return &storage
+}
+
+// Stats 统计地址池的使用情况，只统计在地址池范围中并且没有被排除的地址
+func (s *DhcpStorage) Stats() (PoolStats, error) {
+	network := s.cidr.GetNetwork()
+	stats := PoolStats{
+		Network: network,
+		First:   s.firstAddress(),
+		Last:    s.lastAddress(),
+	}
+	var records []DhcpClient
+	if err := s.db.Where(&DhcpClient{CIDR: s.cidr}).Where("enable = ?", true).Find(&records).Error; err != nil {
+		return stats, err
+	}
+	used := make(map[string]bool)
+	for _, record := range records {
+		if ip := net.IP(record.Address); s.inPool(ip) {
+			used[normalizeIP(ip).String()] = true
+		}
+	}
+	reservations, err := s.GetReservations()
+	if err != nil {
+		return stats, err
+	}
+	var reserved uint64
+	for _, r := range reservations {
+		if ip := net.IP(r.Address); s.inPool(ip) && !used[normalizeIP(ip).String()] {
+			reserved++
+		}
+	}
+
+	total := s.poolSize()
+	free := new(big.Int).Sub(total, new(big.Int).SetUint64(uint64(len(used))+reserved))
+	stats.Total = saturateUint64(total)
+	stats.Used = uint64(len(used))
+	stats.Reserved = reserved
+	stats.Free = saturateUint64(free)
+	return stats, nil
+}
+
+// exhaustedError 地址池中没有可以分配的地址
+func (s *DhcpStorage) exhaustedError() error {
+	network := s.cidr.GetNetwork()
+	return fmt.Errorf("%w: %s (%s-%s)", errs.WgPoolExhaustedError, network.String(),
+		s.firstAddress().String(), s.lastAddress().String())
+}
+
+// firstAddress 地址池中第一个可以分配的地址
+func (s *DhcpStorage) firstAddress() net.IP {
+	return poolFirst(s.cidr.GetNetwork(), s.pool)
+}
+
+// lastAddress 地址池中最后一个可以分配的地址
+func (s *DhcpStorage) lastAddress() net.IP {
+	return poolLast(s.cidr.GetNetwork(), s.pool)
+}
+
+// inPool 地址是否在地址池的范围中并且没有被排除
+func (s *DhcpStorage) inPool(ip net.IP) bool {
+	r := IpRange{Start: s.firstAddress(), End: s.lastAddress()}
+	if !r.Contains(ip) {
+		return false
+	}
+	_, excluded := s.exclusion(ip)
+	return !excluded
+}
+
+// exclusion 获取包含该地址的排除范围
+func (s *DhcpStorage) exclusion(ip net.IP) (IpRange, bool) {
+	for _, r := range s.pool.Exclude {
+		if r.Contains(ip) {
+			return r, true
+		}
+	}
+	return IpRange{}, false
+}
+
+// poolSize 地址池范围中没有被排除的地址数量
+func (s *DhcpStorage) poolSize() *big.Int {
+	first, last := s.firstAddress(), s.lastAddress()
+	size := rangeSize(first, last)
+	// 排除的范围可能重叠，先裁剪到地址池的范围中再合并
+	var ranges []IpRange
+	for _, r := range s.pool.Exclude {
+		r = IpRange{Start: normalizeIP(r.Start), End: normalizeIP(r.End)}
+		if len(r.Start) != len(first) || compareIP(r.End, first) < 0 || compareIP(r.Start, last) > 0 {
+			continue
+		}
+		if compareIP(r.Start, first) < 0 {
+			r.Start = first
+		}
+		if compareIP(r.End, last) > 0 {
+			r.End = last
+		}
+		ranges = append(ranges, r)
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return compareIP(ranges[i].Start, ranges[j].Start) < 0
+	})
+	var merged []IpRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && compareIP(r.Start, dhcp.IpAdd(merged[n-1].End, 1)) <= 0 {
+			if compareIP(r.End, merged[n-1].End) > 0 {
+				merged[n-1].End = r.End
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	for _, r := range merged {
+		size.Sub(size, rangeSize(r.Start, r.End))
+	}
+	return size
+}
+
+// poolFirst 地址池中第一个可以分配的地址，默认跳过网络地址
+func poolFirst(network net.IPNet, pool PoolConfig) net.IP {
+	if pool.Start != nil {
+		return normalizeIP(pool.Start)
+	}
+	return inet.NewNetworkAddress(network).FirstUsable()
+}
+
+// poolLast 地址池中最后一个可以分配的地址，默认 IPv4 跳过广播地址
+func poolLast(network net.IPNet, pool PoolConfig) net.IP {
+	if pool.End != nil {
+		return normalizeIP(pool.End)
+	}
+	return inet.NewNetworkAddress(network).LastUsable()
+}
+
+// rangeSize 两个地址之间的地址数量，包括开始以及结束的地址
+func rangeSize(start, end net.IP) *big.Int {
+	size := new(big.Int).Sub(new(big.Int).SetBytes(end), new(big.Int).SetBytes(start))
+	return size.Add(size, big.NewInt(1))
+}
+
+// saturateUint64 转换为 uint64，超出范围时为 0 或者 math.MaxUint64
+func saturateUint64(n *big.Int) uint64 {
+	switch {
+	case n.Sign() < 0:
+		return 0
+	case !n.IsUint64():
+		return math.MaxUint64
+	}
+	return n.Uint64()
+}
+
+// normalizeIP IPv4 地址使用 4 字节表示，方便比较以及计算
+func normalizeIP(ip net.IP) net.IP {
+	if v4 := ip.To4(); v4 != nil {
+		return v4
+	}
+	return ip
+}
+
+// compareIP 比较两个同一协议的地址
+func compareIP(a, b net.IP) int {
+	return bytes.Compare(normalizeIP(a), normalizeIP(b))
+}
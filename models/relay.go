@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// PeerRelay 节点可以连接的中继节点
+// 节点的 ConnectTo 为当前使用的中继节点，只有该中继节点会转发节点的流量，其他中继节点作为备用
+type PeerRelay struct {
+	ID       uint `gorm:"primarykey"`
+	PeerID   uint `gorm:"column:peer_id;uniqueIndex:idx_peer_relay"`  // 节点
+	RelayID  uint `gorm:"column:relay_id;uniqueIndex:idx_peer_relay"` // 中继节点
+	Priority int  `gorm:"column:priority"`                            // 优先级，越小越优先
+}
+
+// GetRelayPeers 获取所有的中继节点，按创建顺序排序
+func GetRelayPeers(db *gorm.DB) ([]Peer, error) {
+	var relays []Peer
+	if err := db.Where(&Peer{IsServer: true}).Order("id").Find(&relays).Error; err != nil {
+		return nil, err
+	}
+	if len(relays) == 0 {
+		return nil, errs.WgNoRelayPeerError
+	}
+	return relays, nil
+}
+
+// GetRelayPeersByName 按照给定的顺序获取中继节点
+func GetRelayPeersByName(db *gorm.DB, names []string) ([]Peer, error) {
+	relays := make([]Peer, 0, len(names))
+	for _, name := range lo.Uniq(names) {
+		relay, err := GetPeerByName(db, name)
+		if err != nil {
+			return nil, err
+		}
+		if !relay.IsServer {
+			return nil, fmt.Errorf("%w: %s 不是中继节点", errs.WgInvalidRelayError, name)
+		}
+		relays = append(relays, relay)
+	}
+	return relays, nil
+}
+
+// SetPeerRelays 设置节点可以连接的中继节点，relays 按优先级排序
+func SetPeerRelays(db *gorm.DB, peerId uint, relays []Peer) error {
+	if err := DeletePeerRelays(db, peerId); err != nil {
+		return err
+	}
+	records := lo.Map(relays, func(item Peer, index int) PeerRelay {
+		return PeerRelay{PeerID: peerId, RelayID: item.ID, Priority: index}
+	})
+	if len(records) == 0 {
+		return nil
+	}
+	return db.Create(&records).Error
+}
+
+// DeletePeerRelays 删除节点可以连接的中继节点
+func DeletePeerRelays(db *gorm.DB, peerId uint) error {
+	return db.Where("peer_id = ?", peerId).Delete(&PeerRelay{}).Error
+}
+
+// GetRelays 获取节点可以连接的中继节点，按优先级排序
+// 没有设置时只有 ConnectTo 指向的中继节点
+func (p Peer) GetRelays(db *gorm.DB) ([]Peer, error) {
+	var relays []Peer
+	err := db.Joins("JOIN peer_relays ON peer_relays.relay_id = peers.id").
+		Where("peer_relays.peer_id = ?", p.ID).Order("peer_relays.priority").Find(&relays).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(relays) > 0 {
+		return relays, nil
+	}
+	relay, err := p.GetConnectPeer(db)
+	if err != nil {
+		return nil, err
+	}
+	return []Peer{relay}, nil
+}
+
+// GetAttachedPeers 获取可以连接到该中继节点的所有节点
+func (p Peer) GetAttachedPeers(db *gorm.DB) ([]Peer, error) {
+	var peers []Peer
+	err := db.Where("connect_to = ? OR id IN (?)", p.ID,
+		db.Model(&PeerRelay{}).Select("peer_id").Where("relay_id = ?", p.ID)).Find(&peers).Error
+	return peers, err
+}
+
+// ToWgInterRelayConfigs 中继节点之间互相连接的配置
+// 每个中继节点转发自己的地址以及当前使用它的节点的地址，其他中继节点收到发往这些节点的流量时转发给它
+func (p Peer) ToWgInterRelayConfigs(db *gorm.DB) ([]wg.WgPeerConfig, error) {
+	relays, err := GetRelayPeers(db)
+	if err != nil {
+		return nil, err
+	}
+	var configs []wg.WgPeerConfig
+	for _, relay := range relays {
+		if relay.ID == p.ID {
+			continue
+		}
+		pubKey, err := wgtypes.ParseKey(relay.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		var owned []Peer
+		if err = db.Where("connect_to = ?", relay.ID).Find(&owned).Error; err != nil {
+			return nil, err
+		}
+		allowIps := relay.GetHostNetworks()
+		for _, peer := range owned {
+			allowIps = append(allowIps, peer.GetHostNetworks()...)
+			if peer.PeerType == uint(pb.PeerType_SubNet) {
+				allowIps = append(allowIps, peer.PeerSubnetAddress.GetNetworks()...)
+			}
+		}
+		config := wg.WgPeerConfig{
+			InterfaceName: p.InterfaceName,
+			PeerConfig: wgtypes.PeerConfig{
+				PublicKey:                   pubKey,
+				PersistentKeepaliveInterval: p.GetKeepAliveInterval(),
+				ReplaceAllowedIPs:           true,
+				AllowedIPs:                  allowIps,
+			},
+		}
+		if endpoint, err := relay.GetEndpoint(); err == nil {
+			config.PeerConfig.Endpoint = endpoint
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"gorm.io/gorm"
+)
+
+// AddressReservation 地址池中预留给指定节点的地址
+// 通过节点名、公钥或者 MAC 地址中的一个指定节点，预留的地址不会分配给其他节点
+type AddressReservation struct {
+	ID           uint             `gorm:"primarykey"`
+	CIDR         inet.CidrAddress `gorm:"column:cidr;uniqueIndex:idx_reservation_address"`    // 地址池
+	Address      inet.IpAddress   `gorm:"column:address;uniqueIndex:idx_reservation_address"` // 预留的地址
+	PeerName     string           `gorm:"column:peer_name"`                                   // 节点名
+	PublicKey    string           `gorm:"column:public_key"`                                  // 节点公钥
+	HardwareAddr net.HardwareAddr `gorm:"column:mac"`                                         // MAC 地址
+	Remark       string           `gorm:"column:remark"`                                      // 备注
+}
+
+// Matches 预留地址是否属于该节点
+func (r AddressReservation) Matches(peerName, publicKey string, mac net.HardwareAddr) bool {
+	switch {
+	case r.PeerName != "":
+		return r.PeerName == peerName
+	case r.PublicKey != "":
+		return r.PublicKey == publicKey
+	case len(r.HardwareAddr) > 0:
+		return bytes.Equal(r.HardwareAddr, mac)
+	}
+	return false
+}
+
+// AddReservation 在地址池中预留地址，同一个节点在一个地址池中只能预留一个地址
+func (s *DhcpStorage) AddReservation(reservation AddressReservation) (AddressReservation, error) {
+	reservation.CIDR = s.cidr
+	network := s.cidr.GetNetwork()
+	if !network.Contains(net.IP(reservation.Address)) {
+		return reservation, fmt.Errorf("%w: %s 不在 %s 中", errs.WgInvalidAddressError, reservation.Address, network.String())
+	}
+	// 地址已经分配给其他节点时不能预留
+	ip := net.IP(reservation.Address)
+	record, err := s.getRecord(ip)
+	if err != nil {
+		return reservation, err
+	}
+	owner, err := s.addressOwner(ip)
+	if err != nil {
+		return reservation, err
+	}
+	if (record.Enable || owner.ID != 0) && !reservation.Matches(owner.PeerName, owner.PublicKey, record.HardwareAddr) {
+		return reservation, fmt.Errorf("%w: %s 已经被使用", errs.WgInvalidAddressError, reservation.Address)
+	}
+	reservations, err := s.GetReservations()
+	if err != nil {
+		return reservation, err
+	}
+	for _, r := range reservations {
+		if net.IP(r.Address).Equal(net.IP(reservation.Address)) {
+			return reservation, fmt.Errorf("%w: %s", errs.WgReservationExistsError, reservation.Address)
+		}
+		if r.Matches(reservation.PeerName, reservation.PublicKey, reservation.HardwareAddr) {
+			return reservation, fmt.Errorf("%w: 节点已经预留了 %s", errs.WgReservationExistsError, r.Address)
+		}
+	}
+	return reservation, s.db.Create(&reservation).Error
+}
+
+// DeleteReservation 删除预留的地址
+func (s *DhcpStorage) DeleteReservation(ip net.IP) error {
+	result := s.db.Where(&AddressReservation{CIDR: s.cidr, Address: inet.IpAddress(ip)}).Delete(&AddressReservation{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", errs.WgReservationNotFoundError, ip.String())
+	}
+	return nil
+}
+
+// GetReservations 获取地址池中所有预留的地址
+func (s *DhcpStorage) GetReservations() ([]AddressReservation, error) {
+	var reservations []AddressReservation
+	err := s.db.Where(&AddressReservation{CIDR: s.cidr}).Order("id").Find(&reservations).Error
+	return reservations, err
+}
+
+// GetReservation 获取预留给节点的地址，没有预留时返回 nil
+func (s *DhcpStorage) GetReservation(peerName, publicKey string, mac net.HardwareAddr) (*AddressReservation, error) {
+	reservations, err := s.GetReservations()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reservations {
+		if r.Matches(peerName, publicKey, mac) {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// isReserved 地址是否被预留
+func (s *DhcpStorage) isReserved(ip net.IP) (bool, error) {
+	var reservation AddressReservation
+	err := s.db.Where(&AddressReservation{CIDR: s.cidr, Address: inet.IpAddress(ip)}).First(&reservation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// reservedAddresses 查询地址池中预留地址的子查询
+func (s *DhcpStorage) reservedAddresses() *gorm.DB {
+	return s.db.Model(&AddressReservation{}).Select("address").Where(&AddressReservation{CIDR: s.cidr})
+}
+
+// addressOwner 获取使用该地址的节点，没有节点使用时返回空节点
+func (s *DhcpStorage) addressOwner(ip net.IP) (Peer, error) {
+	var peer Peer
+	address := inet.NewCidrAddress(ip, s.cidr.GetNetwork()).String()
+	err := s.db.Where("address = ? OR address6 = ?", address, address).First(&peer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return Peer{}, nil
+	}
+	return peer, err
+}
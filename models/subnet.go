@@ -0,0 +1,190 @@
+package models
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/samber/lo"
+	"gorm.io/gorm"
+)
+
+// SubnetAllocation 从超网中分配给 SubNet 节点的子网
+type SubnetAllocation struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Supernet  inet.CidrAddress `gorm:"column:supernet"`            // 子网所在的超网
+	Network   inet.CidrAddress `gorm:"column:network;uniqueIndex"` // 分配的子网
+	PeerName  string           `gorm:"column:peer_name;index"`     // 使用子网的节点名
+}
+
+// SubnetStorage 从超网中为 SubNet 节点分配互不重叠的子网
+type SubnetStorage struct {
+	db       *gorm.DB
+	supernet net.IPNet
+	prefix   int         // 没有指定前缀长度时分配的子网大小
+	denied   []net.IPNet // 禁止分配的网络
+}
+
+// NewSubnetStorage 初始化超网的子网分配，prefix 为默认分配的前缀长度
+func NewSubnetStorage(db *gorm.DB, supernet net.IPNet, prefix int) *SubnetStorage {
+	return &SubnetStorage{
+		db:       db,
+		supernet: net.IPNet{IP: supernet.IP.Mask(supernet.Mask), Mask: supernet.Mask},
+		prefix:   prefix,
+	}
+}
+
+// WithDenied 返回不会分配 denied 中网络的子网分配
+func (s *SubnetStorage) WithDenied(denied []net.IPNet) *SubnetStorage {
+	storage := *s
+	storage.denied = denied
+	return &storage
+}
+
+// CheckSubnetPrefix 检查前缀长度是否可以用于从超网中分配子网
+func CheckSubnetPrefix(supernet net.IPNet, prefix int) error {
+	ones, bits := supernet.Mask.Size()
+	if prefix < ones || prefix > bits {
+		return fmt.Errorf("%w: /%d 不能从 %s 中分配", errs.WgInvalidPrefixError, prefix, supernet.String())
+	}
+	return nil
+}
+
+// AllocateSubnet 为节点分配一个前缀长度为 prefix 的子网，prefix 为 0 时使用默认的前缀长度
+// 子网不会与已经分配的子网、节点自己声明的子网、中继节点所在的网络以及禁止分配的网络重叠
+func (s *SubnetStorage) AllocateSubnet(peerName string, prefix int) (net.IPNet, error) {
+	if prefix == 0 {
+		prefix = s.prefix
+	}
+	if err := CheckSubnetPrefix(s.supernet, prefix); err != nil {
+		return net.IPNet{}, err
+	}
+	taken, err := s.takenRanges()
+	if err != nil {
+		return net.IPNet{}, err
+	}
+	_, bits := s.supernet.Mask.Size()
+	size := new(big.Int).Lsh(big.NewInt(1), uint(bits-prefix))
+	first, last := networkRange(s.supernet)
+	// 已经使用的范围按开始地址排序，候选的子网与某个范围重叠时跳到该范围之后对齐的位置
+	candidate := new(big.Int).Set(first)
+	for _, r := range taken {
+		end := new(big.Int).Add(candidate, size)
+		end.Sub(end, big.NewInt(1))
+		if end.Cmp(r[0]) < 0 {
+			break
+		}
+		if r[1].Cmp(candidate) < 0 {
+			continue
+		}
+		candidate.Add(r[1], big.NewInt(1))
+		alignUp(candidate, size)
+	}
+	end := new(big.Int).Add(candidate, size)
+	if end.Sub(end, big.NewInt(1)).Cmp(last) > 0 {
+		return net.IPNet{}, fmt.Errorf("%w: %s 中没有 /%d 的子网", errs.WgSubnetExhaustedError, s.supernet.String(), prefix)
+	}
+
+	network := net.IPNet{
+		IP:   candidate.FillBytes(make(net.IP, len(s.supernet.IP))),
+		Mask: net.CIDRMask(prefix, bits),
+	}
+	allocation := SubnetAllocation{
+		Supernet: inet.NewCidrAddress(s.supernet.IP, s.supernet),
+		Network:  inet.NewCidrAddress(network.IP, network),
+		PeerName: peerName,
+	}
+	return network, s.db.Create(&allocation).Error
+}
+
+// GetSubnetAllocations 获取超网中所有已经分配的子网
+func (s *SubnetStorage) GetSubnetAllocations() ([]SubnetAllocation, error) {
+	var allocations []SubnetAllocation
+	supernet := inet.NewCidrAddress(s.supernet.IP, s.supernet)
+	err := s.db.Where(&SubnetAllocation{Supernet: supernet}).Order("id").Find(&allocations).Error
+	return allocations, err
+}
+
+// ReleasePeerSubnets 释放分配给节点的所有子网
+func ReleasePeerSubnets(db *gorm.DB, peerName string) error {
+	return db.Where("peer_name = ?", peerName).Delete(&SubnetAllocation{}).Error
+}
+
+// ReleaseUnusedSubnets 释放分配给节点但与 subnets 都不重叠的子网，节点修改子网地址之后调用
+// 节点仍然使用分配的子网的一部分时保留整个子网
+func ReleaseUnusedSubnets(db *gorm.DB, peerName string, subnets []inet.CidrAddress) error {
+	var allocations []SubnetAllocation
+	if err := db.Where("peer_name = ?", peerName).Find(&allocations).Error; err != nil {
+		return err
+	}
+	var unused []uint
+	for _, allocation := range allocations {
+		used := lo.ContainsBy(subnets, func(item inet.CidrAddress) bool {
+			return item.Overlaps(allocation.Network)
+		})
+		if !used {
+			unused = append(unused, allocation.ID)
+		}
+	}
+	if len(unused) == 0 {
+		return nil
+	}
+	return db.Delete(&SubnetAllocation{}, unused).Error
+}
+
+// takenRanges 获取与超网重叠的已经使用的地址范围，按开始地址排序
+func (s *SubnetStorage) takenRanges() ([][2]*big.Int, error) {
+	networks := append([]net.IPNet(nil), s.denied...)
+	var allocations []SubnetAllocation
+	if err := s.db.Find(&allocations).Error; err != nil {
+		return nil, err
+	}
+	for _, allocation := range allocations {
+		networks = append(networks, allocation.Network.GetNetwork())
+	}
+	var peers []Peer
+	if err := s.db.Find(&peers).Error; err != nil {
+		return nil, err
+	}
+	for _, peer := range peers {
+		networks = append(networks, peer.PeerSubnetAddress.GetNetworks()...)
+		if peer.IsServer {
+			networks = append(networks, peer.GetNetworks()...)
+		}
+	}
+
+	var ranges [][2]*big.Int
+	for _, network := range networks {
+		if !networksOverlap(s.supernet, network) {
+			continue
+		}
+		first, last := networkRange(network)
+		ranges = append(ranges, [2]*big.Int{first, last})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0].Cmp(ranges[j][0]) < 0
+	})
+	return ranges, nil
+}
+
+// networkRange 网络中第一个以及最后一个地址
+func networkRange(network net.IPNet) (*big.Int, *big.Int) {
+	ip := normalizeIP(network.IP.Mask(network.Mask))
+	ones, bits := network.Mask.Size()
+	first := new(big.Int).SetBytes(ip)
+	last := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+	last.Add(last, first).Sub(last, big.NewInt(1))
+	return first, last
+}
+
+// alignUp 将 n 向上对齐到 size 的整数倍
+func alignUp(n, size *big.Int) {
+	if mod := new(big.Int).Mod(n, size); mod.Sign() != 0 {
+		n.Add(n, size).Sub(n, mod)
+	}
+}
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// Topology 网络拓扑
+type Topology string
+
+const (
+	TopologyHub    Topology = "hub"    // 所有节点都经过中继节点转发
+	TopologyMesh   Topology = "mesh"   // 所有节点之间都直接连接
+	TopologyHybrid Topology = "hybrid" // 只有 Mesh 节点之间直接连接
+)
+
+// ParseTopology 解析网络拓扑，为空时使用 hub
+func ParseTopology(s string) (Topology, error) {
+	switch topology := Topology(s); topology {
+	case "":
+		return TopologyHub, nil
+	case TopologyHub, TopologyMesh, TopologyHybrid:
+		return topology, nil
+	}
+	return "", fmt.Errorf("%w: %s", errs.WgInvalidTopologyError, s)
+}
+
+// isMeshPeer 在该拓扑下节点是否参与直接连接
+func (t Topology) isMeshPeer(p Peer) bool {
+	switch t {
+	case TopologyMesh:
+		return true
+	case TopologyHybrid:
+		return p.Mesh
+	}
+	return false
+}
+
+// ToWgPeerConfigs 获取节点 wg 设备上需要的所有节点配置
+// 两个节点都参与直接连接，并且至少有一个节点有公网端点时直接连接，其他的流量都经过中继节点转发
+// pskRequired 为 true 时，两个节点中有一个没有预共享密钥时不直接连接，流量经过中继节点转发
+// 返回的配置中先是按优先级排序的中继节点，然后是直接连接的节点
+func (p Peer) ToWgPeerConfigs(db *gorm.DB, topology Topology, pskRequired bool) ([]wg.WgPeerConfig, error) {
+	var directPeers []Peer
+	if topology.isMeshPeer(p) {
+		var peers []Peer
+		if err := db.Where("is_server = ? AND id <> ?", false, p.ID).Find(&peers).Error; err != nil {
+			return nil, err
+		}
+		_, endpointErr := p.GetEndpoint()
+		for _, peer := range peers {
+			if !topology.isMeshPeer(peer) {
+				continue
+			}
+			if pskRequired && (p.PresharedKey == "" || peer.PresharedKey == "") {
+				continue
+			}
+			if _, err := peer.GetEndpoint(); err == nil || endpointErr == nil {
+				directPeers = append(directPeers, peer)
+			}
+		}
+	}
+
+	relayConfig, err := p.ToWgPeerConfig(db)
+	if err != nil {
+		return nil, err
+	}
+	// 直接连接的节点的子网不再经过中继节点转发
+	direct := make(map[string]bool)
+	for _, peer := range directPeers {
+		for _, network := range peer.PeerSubnetAddress.GetNetworks() {
+			direct[network.String()] = true
+		}
+	}
+	var allowIps []net.IPNet
+	for _, network := range relayConfig.PeerConfig.AllowedIPs {
+		if !direct[network.String()] {
+			allowIps = append(allowIps, network)
+		}
+	}
+	relayConfig.PeerConfig.AllowedIPs = allowIps
+	configs, err := p.toWgRelayConfigs(db, relayConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, peer := range directPeers {
+		config, err := p.toWgDirectPeerConfig(peer)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// FailoverKeepAliveInterval 节点没有设置保活时长并且有多个中继节点时，与中继节点之间的保活时长
+const FailoverKeepAliveInterval = 25 * time.Second
+
+// toWgRelayConfigs 按优先级排序的所有中继节点的配置，只有当前使用的中继节点有 AllowedIPs
+// 有多个中继节点时，没有设置保活时长的节点使用 FailoverKeepAliveInterval
+func (p Peer) toWgRelayConfigs(db *gorm.DB, active wg.WgPeerConfig) ([]wg.WgPeerConfig, error) {
+	relays, err := p.GetRelays(db)
+	if err != nil {
+		return nil, err
+	}
+	configs := make([]wg.WgPeerConfig, 0, len(relays))
+	found := false
+	for _, relay := range relays {
+		if relay.ID == p.ConnectTo {
+			configs = append(configs, active)
+			found = true
+			continue
+		}
+		config, err := p.toWgRelayConfig(relay, nil)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	if !found {
+		configs = append([]wg.WgPeerConfig{active}, configs...)
+	}
+	// 节点依靠握手判断中继节点是否可用，有多个中继节点时必须保活
+	if len(configs) > 1 {
+		for i := range configs {
+			if configs[i].PeerConfig.PersistentKeepaliveInterval == nil {
+				keepalive := FailoverKeepAliveInterval
+				configs[i].PeerConfig.PersistentKeepaliveInterval = &keepalive
+			}
+		}
+	}
+	return configs, nil
+}
+
+// toWgDirectPeerConfig 直接连接的节点的配置
+func (p Peer) toWgDirectPeerConfig(peer Peer) (wg.WgPeerConfig, error) {
+	var config wg.WgPeerConfig
+	pubKey, err := wgtypes.ParseKey(peer.PublicKey)
+	if err != nil {
+		return config, err
+	}
+	psk, err := p.directPresharedKey(peer)
+	if err != nil {
+		return config, err
+	}
+	allowIps := peer.GetHostNetworks()
+	if peer.PeerType == uint(pb.PeerType_SubNet) {
+		allowIps = append(allowIps, peer.PeerSubnetAddress.GetNetworks()...)
+	}
+	config = wg.WgPeerConfig{
+		InterfaceName: p.InterfaceName,
+		PeerConfig: wgtypes.PeerConfig{
+			PublicKey:                   pubKey,
+			PresharedKey:                psk,
+			PersistentKeepaliveInterval: p.GetKeepAliveInterval(),
+			ReplaceAllowedIPs:           true,
+			AllowedIPs:                  allowIps,
+		},
+	}
+	if endpoint, err := peer.GetEndpoint(); err == nil {
+		config.PeerConfig.Endpoint = endpoint
+	}
+	return config, nil
+}
+
+// directPresharedKey 两个直接连接的节点之间的预共享密钥
+// 两个节点都使用预共享密钥时，由两个节点的预共享密钥派生，两端得到的结果相同
+func (p Peer) directPresharedKey(peer Peer) (*wgtypes.Key, error) {
+	psk1, err := p.GetPresharedKey()
+	if err != nil || psk1 == nil {
+		return nil, err
+	}
+	psk2, err := peer.GetPresharedKey()
+	if err != nil || psk2 == nil {
+		return nil, err
+	}
+	if p.PublicKey > peer.PublicKey {
+		psk1, psk2 = psk2, psk1
+	}
+	key := wgtypes.Key(sha256.Sum256(append(psk1[:], psk2[:]...)))
+	return &key, nil
+}
@@ -123,6 +123,26 @@ type RegisterPeerReq struct {
 	PeerType PeerType `protobuf:"varint,2,opt,name=peer_type,json=peerType,proto3,enum=protocol.PeerType" json:"peer_type,omitempty"`
 	// 子网地址
 	SubNets []*CidrAddress `protobuf:"bytes,3,rep,name=sub_nets,json=subNets,proto3" json:"sub_nets,omitempty"`
+	// 节点公钥，由节点自己生成密钥对时设置，私钥不会离开节点
+	// 为空时由服务端生成密钥对
+	Pubkey string `protobuf:"bytes,4,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	// 是否为节点与中继节点之间的连接生成预共享密钥
+	// 服务端要求必须使用预共享密钥时忽略该字段
+	PresharedKey bool `protobuf:"varint,5,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
+	// 是否与其他节点直接连接，只在 hybrid 拓扑下生效
+	Mesh bool `protobuf:"varint,6,opt,name=mesh,proto3" json:"mesh,omitempty"`
+	// 节点的公网端点，格式为 ip:port，为空表示节点没有公网地址
+	Endpoint string `protobuf:"bytes,7,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// 按优先级排序的中继节点名，为空时使用所有的中继节点
+	RelayNames []string `protobuf:"bytes,8,rep,name=relay_names,json=relayNames,proto3" json:"relay_names,omitempty"`
+	// 是否为静态节点，静态节点的地址租约不会过期
+	Static bool `protobuf:"varint,9,opt,name=static,proto3" json:"static,omitempty"`
+	// 节点的 MAC 地址，用于匹配按 MAC 地址预留的地址，为空时根据节点名生成
+	HardwareAddr string `protobuf:"bytes,10,opt,name=hardware_addr,json=hardwareAddr,proto3" json:"hardware_addr,omitempty"`
+	// 是否由服务端从超网中为 SubNet 节点分配子网，分配的子网追加在 sub_nets 之后
+	AllocateSubnet bool `protobuf:"varint,11,opt,name=allocate_subnet,json=allocateSubnet,proto3" json:"allocate_subnet,omitempty"`
+	// 分配的子网的前缀长度，为 0 时使用服务端配置的长度
+	SubnetPrefix uint32 `protobuf:"varint,12,opt,name=subnet_prefix,json=subnetPrefix,proto3" json:"subnet_prefix,omitempty"`
 }
 
 func (x *RegisterPeerReq) Reset() {
@@ -178,6 +198,69 @@ func (x *RegisterPeerReq) GetSubNets() []*CidrAddress {
 	return nil
 }
 
+func (x *RegisterPeerReq) GetPubkey() string {
+	if x != nil {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *RegisterPeerReq) GetPresharedKey() bool {
+	if x != nil {
+		return x.PresharedKey
+	}
+	return false
+}
+
+func (x *RegisterPeerReq) GetMesh() bool {
+	if x != nil {
+		return x.Mesh
+	}
+	return false
+}
+
+func (x *RegisterPeerReq) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *RegisterPeerReq) GetRelayNames() []string {
+	if x != nil {
+		return x.RelayNames
+	}
+	return nil
+}
+
+func (x *RegisterPeerReq) GetStatic() bool {
+	if x != nil {
+		return x.Static
+	}
+	return false
+}
+
+func (x *RegisterPeerReq) GetHardwareAddr() string {
+	if x != nil {
+		return x.HardwareAddr
+	}
+	return ""
+}
+
+func (x *RegisterPeerReq) GetAllocateSubnet() bool {
+	if x != nil {
+		return x.AllocateSubnet
+	}
+	return false
+}
+
+func (x *RegisterPeerReq) GetSubnetPrefix() uint32 {
+	if x != nil {
+		return x.SubnetPrefix
+	}
+	return 0
+}
+
 // 定义节点返回的信息
 type RegisterPeerRsp struct {
 	state         protoimpl.MessageState
@@ -186,12 +269,18 @@ type RegisterPeerRsp struct {
 
 	// 节点公钥
 	Pubkey string `protobuf:"bytes,1,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
-	// 节点私钥
+	// 节点私钥，节点自己携带公钥注册时为空
 	Prikey string `protobuf:"bytes,2,opt,name=prikey,proto3" json:"prikey,omitempty"`
 	// 节点地址
 	Address *CidrAddress `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
-	// 中继节点信息
-	RelayPeerInfo *RelayPeerInfo `protobuf:"bytes,4,opt,name=relay_peer_info,json=relayPeerInfo,proto3" json:"relay_peer_info,omitempty"`
+	// 按优先级排序的中继节点信息，第一个为当前使用的中继节点
+	RelayPeerInfo []*RelayPeerInfo `protobuf:"bytes,4,rep,name=relay_peer_info,json=relayPeerInfo,proto3" json:"relay_peer_info,omitempty"`
+	// 节点 IPv6 地址，网络没有开启双栈时为空
+	Address6 *CidrAddress `protobuf:"bytes,5,opt,name=address6,proto3" json:"address6,omitempty"`
+	// 地址租约到期的 unix 时间戳，单位秒，为 0 时不会过期，节点需要在到期之前调用 RenewLease 续租
+	ExpireTime int64 `protobuf:"varint,6,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+	// 节点的子网地址，包括服务端分配的子网
+	SubNets []*CidrAddress `protobuf:"bytes,7,rep,name=sub_nets,json=subNets,proto3" json:"sub_nets,omitempty"`
 }
 
 func (x *RegisterPeerRsp) Reset() {
@@ -247,13 +336,34 @@ func (x *RegisterPeerRsp) GetAddress() *CidrAddress {
 	return nil
 }
 
-func (x *RegisterPeerRsp) GetRelayPeerInfo() *RelayPeerInfo {
+func (x *RegisterPeerRsp) GetRelayPeerInfo() []*RelayPeerInfo {
 	if x != nil {
 		return x.RelayPeerInfo
 	}
 	return nil
 }
 
+func (x *RegisterPeerRsp) GetAddress6() *CidrAddress {
+	if x != nil {
+		return x.Address6
+	}
+	return nil
+}
+
+func (x *RegisterPeerRsp) GetExpireTime() int64 {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return 0
+}
+
+func (x *RegisterPeerRsp) GetSubNets() []*CidrAddress {
+	if x != nil {
+		return x.SubNets
+	}
+	return nil
+}
+
 type CidrAddress struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -311,6 +421,10 @@ type RelayPeerInfo struct {
 	Endpoint string `protobuf:"bytes,1,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
 	// 公钥
 	Pubkey string `protobuf:"bytes,2,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	// 与中继节点之间的预共享密钥，没有使用时为空
+	PresharedKey string `protobuf:"bytes,3,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
+	// 中继节点名
+	PeerName string `protobuf:"bytes,4,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
 }
 
 func (x *RelayPeerInfo) Reset() {
@@ -359,6 +473,20 @@ func (x *RelayPeerInfo) GetPubkey() string {
 	return ""
 }
 
+func (x *RelayPeerInfo) GetPresharedKey() string {
+	if x != nil {
+		return x.PresharedKey
+	}
+	return ""
+}
+
+func (x *RelayPeerInfo) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
 // 定义如何注册一个Peer节点
 type UnregisterPeerReq struct {
 	state         protoimpl.MessageState
@@ -408,151 +536,1816 @@ func (x *UnregisterPeerReq) GetPeerName() string {
 	return ""
 }
 
-var File_protocols_wg_proto protoreflect.FileDescriptor
+// 节点信息，不包含节点的私钥
+type PeerInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
 
-var file_protocols_wg_proto_rawDesc = []byte{
-	0x0a, 0x12, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x2f, 0x77, 0x67, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0x0a,
-	0x0a, 0x08, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x52, 0x73, 0x70, 0x22, 0x91, 0x01, 0x0a, 0x0f, 0x52,
-	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1b,
-	0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x70,
-	0x65, 0x65, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79,
-	0x70, 0x65, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x30, 0x0a, 0x08,
-	0x73, 0x75, 0x62, 0x5f, 0x6e, 0x65, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64,
-	0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x73, 0x75, 0x62, 0x4e, 0x65, 0x74, 0x73, 0x22, 0xb3,
-	0x01, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52,
-	0x73, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72,
-	0x69, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x69, 0x6b,
-	0x65, 0x79, 0x12, 0x2f, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20,
-	0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43,
-	0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72,
-	0x65, 0x73, 0x73, 0x12, 0x3f, 0x0a, 0x0f, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x70, 0x65, 0x65,
-	0x72, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x50, 0x65, 0x65,
-	0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0d, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x50, 0x65, 0x65, 0x72,
-	0x49, 0x6e, 0x66, 0x6f, 0x22, 0x27, 0x0a, 0x0b, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72,
-	0x65, 0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x43, 0x0a,
-	0x0d, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x50, 0x65, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1a,
-	0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x75,
-	0x62, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b,
-	0x65, 0x79, 0x22, 0x30, 0x0a, 0x11, 0x55, 0x6e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
-	0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72,
-	0x4e, 0x61, 0x6d, 0x65, 0x2a, 0x2c, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65,
-	0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x07, 0x0a,
-	0x03, 0x50, 0x32, 0x50, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x75, 0x62, 0x4e, 0x65, 0x74,
-	0x10, 0x02, 0x32, 0x9c, 0x01, 0x0a, 0x0d, 0x57, 0x69, 0x72, 0x65, 0x67, 0x75, 0x61, 0x72, 0x64,
-	0x54, 0x6f, 0x6f, 0x6c, 0x12, 0x46, 0x0a, 0x0c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72,
-	0x50, 0x65, 0x65, 0x72, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
-	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a,
-	0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
-	0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x73, 0x70, 0x22, 0x00, 0x12, 0x43, 0x0a, 0x0e,
-	0x55, 0x6e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x12, 0x1b,
-	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x55, 0x6e, 0x72, 0x65, 0x67, 0x69,
-	0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72,
-	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x52, 0x73, 0x70, 0x22,
-	0x00, 0x42, 0x2e, 0x5a, 0x2c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
-	0x6f, 0x6e, 0x65, 0x73, 0x61, 0x6c, 0x74, 0x65, 0x64, 0x73, 0x65, 0x61, 0x66, 0x69, 0x73, 0x68,
-	0x2f, 0x77, 0x67, 0x2d, 0x74, 0x6f, 0x6f, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
-	0x6c, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
+	// 接口名
+	InterfaceName string `protobuf:"bytes,2,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// 节点类型
+	PeerType PeerType `protobuf:"varint,3,opt,name=peer_type,json=peerType,proto3,enum=protocol.PeerType" json:"peer_type,omitempty"`
+	// 是否为中继节点
+	IsServer bool `protobuf:"varint,4,opt,name=is_server,json=isServer,proto3" json:"is_server,omitempty"`
+	// 节点公钥
+	Pubkey string `protobuf:"bytes,5,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	// 节点地址
+	Address *CidrAddress `protobuf:"bytes,6,opt,name=address,proto3" json:"address,omitempty"`
+	// 子网地址
+	SubNets []*CidrAddress `protobuf:"bytes,7,rep,name=sub_nets,json=subNets,proto3" json:"sub_nets,omitempty"`
+	// 节点的端点信息，没有公网地址的节点为空
+	Endpoint string `protobuf:"bytes,8,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// 保持心跳的时间间隔，单位秒
+	KeepAliveInterval int32 `protobuf:"varint,9,opt,name=keep_alive_interval,json=keepAliveInterval,proto3" json:"keep_alive_interval,omitempty"`
+	// 备注
+	Remark string `protobuf:"bytes,10,opt,name=remark,proto3" json:"remark,omitempty"`
+	// 是否与其他节点直接连接
+	Mesh bool `protobuf:"varint,11,opt,name=mesh,proto3" json:"mesh,omitempty"`
+	// 节点 IPv6 地址，网络没有开启双栈时为空
+	Address6 *CidrAddress `protobuf:"bytes,12,opt,name=address6,proto3" json:"address6,omitempty"`
+	// 是否为静态节点
+	Static bool `protobuf:"varint,13,opt,name=static,proto3" json:"static,omitempty"`
 }
 
-var (
-	file_protocols_wg_proto_rawDescOnce sync.Once
-	file_protocols_wg_proto_rawDescData = file_protocols_wg_proto_rawDesc
-)
+func (x *PeerInfo) Reset() {
+	*x = PeerInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
 
-func file_protocols_wg_proto_rawDescGZIP() []byte {
-	file_protocols_wg_proto_rawDescOnce.Do(func() {
-		file_protocols_wg_proto_rawDescData = protoimpl.X.CompressGZIP(file_protocols_wg_proto_rawDescData)
-	})
-	return file_protocols_wg_proto_rawDescData
+func (x *PeerInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-var file_protocols_wg_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_protocols_wg_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
-var file_protocols_wg_proto_goTypes = []interface{}{
-	(PeerType)(0),             // 0: protocol.PeerType
-	(*EmptyRsp)(nil),          // 1: protocol.EmptyRsp
-	(*RegisterPeerReq)(nil),   // 2: protocol.RegisterPeerReq
-	(*RegisterPeerRsp)(nil),   // 3: protocol.RegisterPeerRsp
-	(*CidrAddress)(nil),       // 4: protocol.CidrAddress
-	(*RelayPeerInfo)(nil),     // 5: protocol.RelayPeerInfo
-	(*UnregisterPeerReq)(nil), // 6: protocol.UnregisterPeerReq
+func (*PeerInfo) ProtoMessage() {}
+
+func (x *PeerInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
-var file_protocols_wg_proto_depIdxs = []int32{
-	0, // 0: protocol.RegisterPeerReq.peer_type:type_name -> protocol.PeerType
-	4, // 1: protocol.RegisterPeerReq.sub_nets:type_name -> protocol.CidrAddress
-	4, // 2: protocol.RegisterPeerRsp.address:type_name -> protocol.CidrAddress
-	5, // 3: protocol.RegisterPeerRsp.relay_peer_info:type_name -> protocol.RelayPeerInfo
-	2, // 4: protocol.WireguardTool.RegisterPeer:input_type -> protocol.RegisterPeerReq
-	6, // 5: protocol.WireguardTool.UnregisterPeer:input_type -> protocol.UnregisterPeerReq
-	3, // 6: protocol.WireguardTool.RegisterPeer:output_type -> protocol.RegisterPeerRsp
-	1, // 7: protocol.WireguardTool.UnregisterPeer:output_type -> protocol.EmptyRsp
-	6, // [6:8] is the sub-list for method output_type
-	4, // [4:6] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+
+// Deprecated: Use PeerInfo.ProtoReflect.Descriptor instead.
+func (*PeerInfo) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{6}
 }
 
-func init() { file_protocols_wg_proto_init() }
-func file_protocols_wg_proto_init() {
-	if File_protocols_wg_proto != nil {
-		return
+func (x *PeerInfo) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_protocols_wg_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*EmptyRsp); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_protocols_wg_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RegisterPeerReq); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_protocols_wg_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*RegisterPeerRsp); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
+	return ""
+}
+
+func (x *PeerInfo) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *PeerInfo) GetPeerType() PeerType {
+	if x != nil {
+		return x.PeerType
+	}
+	return PeerType_Unknown
+}
+
+func (x *PeerInfo) GetIsServer() bool {
+	if x != nil {
+		return x.IsServer
+	}
+	return false
+}
+
+func (x *PeerInfo) GetPubkey() string {
+	if x != nil {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *PeerInfo) GetAddress() *CidrAddress {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *PeerInfo) GetSubNets() []*CidrAddress {
+	if x != nil {
+		return x.SubNets
+	}
+	return nil
+}
+
+func (x *PeerInfo) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *PeerInfo) GetKeepAliveInterval() int32 {
+	if x != nil {
+		return x.KeepAliveInterval
+	}
+	return 0
+}
+
+func (x *PeerInfo) GetRemark() string {
+	if x != nil {
+		return x.Remark
+	}
+	return ""
+}
+
+func (x *PeerInfo) GetMesh() bool {
+	if x != nil {
+		return x.Mesh
+	}
+	return false
+}
+
+func (x *PeerInfo) GetAddress6() *CidrAddress {
+	if x != nil {
+		return x.Address6
+	}
+	return nil
+}
+
+func (x *PeerInfo) GetStatic() bool {
+	if x != nil {
+		return x.Static
+	}
+	return false
+}
+
+// 分页查询节点
+type ListPeersReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 每页的数量，为 0 时使用默认值
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// 上一页返回的 next_page_token，为空时从第一页开始
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// 按接口名过滤
+	InterfaceName string `protobuf:"bytes,3,opt,name=interface_name,json=interfaceName,proto3" json:"interface_name,omitempty"`
+	// 按节点类型过滤，Unknown 表示不过滤
+	PeerType PeerType `protobuf:"varint,4,opt,name=peer_type,json=peerType,proto3,enum=protocol.PeerType" json:"peer_type,omitempty"`
+	// 按是否为中继节点过滤，不设置表示不过滤
+	IsServer *bool `protobuf:"varint,5,opt,name=is_server,json=isServer,proto3,oneof" json:"is_server,omitempty"`
+	// 按节点名前缀过滤
+	NamePrefix string `protobuf:"bytes,6,opt,name=name_prefix,json=namePrefix,proto3" json:"name_prefix,omitempty"`
+}
+
+func (x *ListPeersReq) Reset() {
+	*x = ListPeersReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPeersReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPeersReq) ProtoMessage() {}
+
+func (x *ListPeersReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
-		file_protocols_wg_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*CidrAddress); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPeersReq.ProtoReflect.Descriptor instead.
+func (*ListPeersReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListPeersReq) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListPeersReq) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListPeersReq) GetInterfaceName() string {
+	if x != nil {
+		return x.InterfaceName
+	}
+	return ""
+}
+
+func (x *ListPeersReq) GetPeerType() PeerType {
+	if x != nil {
+		return x.PeerType
+	}
+	return PeerType_Unknown
+}
+
+func (x *ListPeersReq) GetIsServer() bool {
+	if x != nil && x.IsServer != nil {
+		return *x.IsServer
+	}
+	return false
+}
+
+func (x *ListPeersReq) GetNamePrefix() string {
+	if x != nil {
+		return x.NamePrefix
+	}
+	return ""
+}
+
+type ListPeersRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Peers []*PeerInfo `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"`
+	// 下一页的 page_token，为空表示没有更多的数据
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+}
+
+func (x *ListPeersRsp) Reset() {
+	*x = ListPeersRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListPeersRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPeersRsp) ProtoMessage() {}
+
+func (x *ListPeersRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPeersRsp.ProtoReflect.Descriptor instead.
+func (*ListPeersRsp) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListPeersRsp) GetPeers() []*PeerInfo {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *ListPeersRsp) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// 按节点名或者公钥查询节点
+type GetPeerReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Key:
+	//	*GetPeerReq_PeerName
+	//	*GetPeerReq_Pubkey
+	Key isGetPeerReq_Key `protobuf_oneof:"key"`
+}
+
+func (x *GetPeerReq) Reset() {
+	*x = GetPeerReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPeerReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPeerReq) ProtoMessage() {}
+
+func (x *GetPeerReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPeerReq.ProtoReflect.Descriptor instead.
+func (*GetPeerReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{9}
+}
+
+func (m *GetPeerReq) GetKey() isGetPeerReq_Key {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (x *GetPeerReq) GetPeerName() string {
+	if x, ok := x.GetKey().(*GetPeerReq_PeerName); ok {
+		return x.PeerName
+	}
+	return ""
+}
+
+func (x *GetPeerReq) GetPubkey() string {
+	if x, ok := x.GetKey().(*GetPeerReq_Pubkey); ok {
+		return x.Pubkey
+	}
+	return ""
+}
+
+type isGetPeerReq_Key interface {
+	isGetPeerReq_Key()
+}
+
+type GetPeerReq_PeerName struct {
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3,oneof"`
+}
+
+type GetPeerReq_Pubkey struct {
+	// 节点公钥
+	Pubkey string `protobuf:"bytes,2,opt,name=pubkey,proto3,oneof"`
+}
+
+func (*GetPeerReq_PeerName) isGetPeerReq_Key() {}
+
+func (*GetPeerReq_Pubkey) isGetPeerReq_Key() {}
+
+// 修改节点的配置，只修改设置了的字段
+type UpdatePeerReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
+	// 节点类型
+	PeerType *PeerType `protobuf:"varint,2,opt,name=peer_type,json=peerType,proto3,enum=protocol.PeerType,oneof" json:"peer_type,omitempty"`
+	// 子网地址，设置时替换节点所有的子网地址
+	SubNets *SubnetList `protobuf:"bytes,3,opt,name=sub_nets,json=subNets,proto3" json:"sub_nets,omitempty"`
+	// 保持心跳的时间间隔，单位秒，0 表示关闭
+	KeepAliveInterval *int32 `protobuf:"varint,4,opt,name=keep_alive_interval,json=keepAliveInterval,proto3,oneof" json:"keep_alive_interval,omitempty"`
+	// 备注
+	Remark *string `protobuf:"bytes,5,opt,name=remark,proto3,oneof" json:"remark,omitempty"`
+	// 是否与其他节点直接连接
+	Mesh *bool `protobuf:"varint,6,opt,name=mesh,proto3,oneof" json:"mesh,omitempty"`
+	// 节点的公网端点，格式为 ip:port，设置为空表示节点没有公网地址
+	Endpoint *string `protobuf:"bytes,7,opt,name=endpoint,proto3,oneof" json:"endpoint,omitempty"`
+	// 是否为静态节点
+	Static *bool `protobuf:"varint,8,opt,name=static,proto3,oneof" json:"static,omitempty"`
+}
+
+func (x *UpdatePeerReq) Reset() {
+	*x = UpdatePeerReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdatePeerReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdatePeerReq) ProtoMessage() {}
+
+func (x *UpdatePeerReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdatePeerReq.ProtoReflect.Descriptor instead.
+func (*UpdatePeerReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdatePeerReq) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
+func (x *UpdatePeerReq) GetPeerType() PeerType {
+	if x != nil && x.PeerType != nil {
+		return *x.PeerType
+	}
+	return PeerType_Unknown
+}
+
+func (x *UpdatePeerReq) GetSubNets() *SubnetList {
+	if x != nil {
+		return x.SubNets
+	}
+	return nil
+}
+
+func (x *UpdatePeerReq) GetKeepAliveInterval() int32 {
+	if x != nil && x.KeepAliveInterval != nil {
+		return *x.KeepAliveInterval
+	}
+	return 0
+}
+
+func (x *UpdatePeerReq) GetRemark() string {
+	if x != nil && x.Remark != nil {
+		return *x.Remark
+	}
+	return ""
+}
+
+func (x *UpdatePeerReq) GetMesh() bool {
+	if x != nil && x.Mesh != nil {
+		return *x.Mesh
+	}
+	return false
+}
+
+func (x *UpdatePeerReq) GetEndpoint() string {
+	if x != nil && x.Endpoint != nil {
+		return *x.Endpoint
+	}
+	return ""
+}
+
+func (x *UpdatePeerReq) GetStatic() bool {
+	if x != nil && x.Static != nil {
+		return *x.Static
+	}
+	return false
+}
+
+// 子网地址列表
+type SubnetList struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SubNets []*CidrAddress `protobuf:"bytes,1,rep,name=sub_nets,json=subNets,proto3" json:"sub_nets,omitempty"`
+}
+
+func (x *SubnetList) Reset() {
+	*x = SubnetList{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubnetList) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubnetList) ProtoMessage() {}
+
+func (x *SubnetList) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubnetList.ProtoReflect.Descriptor instead.
+func (*SubnetList) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *SubnetList) GetSubNets() []*CidrAddress {
+	if x != nil {
+		return x.SubNets
+	}
+	return nil
+}
+
+// 获取节点 wg 设备需要的配置
+type GetPeerConfigReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
+}
+
+func (x *GetPeerConfigReq) Reset() {
+	*x = GetPeerConfigReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPeerConfigReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPeerConfigReq) ProtoMessage() {}
+
+func (x *GetPeerConfigReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPeerConfigReq.ProtoReflect.Descriptor instead.
+func (*GetPeerConfigReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetPeerConfigReq) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
+// 节点 wg 设备需要的配置
+type PeerConfigRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 节点地址
+	Address *CidrAddress `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// 节点监听的端口，为 0 表示不固定端口
+	ListenPort int32 `protobuf:"varint,2,opt,name=listen_port,json=listenPort,proto3" json:"listen_port,omitempty"`
+	// 节点需要连接的所有节点，包括中继节点以及直接连接的节点
+	Peers []*RemotePeer `protobuf:"bytes,3,rep,name=peers,proto3" json:"peers,omitempty"`
+	// 节点 IPv6 地址，网络没有开启双栈时为空
+	Address6 *CidrAddress `protobuf:"bytes,4,opt,name=address6,proto3" json:"address6,omitempty"`
+}
+
+func (x *PeerConfigRsp) Reset() {
+	*x = PeerConfigRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PeerConfigRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PeerConfigRsp) ProtoMessage() {}
+
+func (x *PeerConfigRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PeerConfigRsp.ProtoReflect.Descriptor instead.
+func (*PeerConfigRsp) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PeerConfigRsp) GetAddress() *CidrAddress {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *PeerConfigRsp) GetListenPort() int32 {
+	if x != nil {
+		return x.ListenPort
+	}
+	return 0
+}
+
+func (x *PeerConfigRsp) GetPeers() []*RemotePeer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+func (x *PeerConfigRsp) GetAddress6() *CidrAddress {
+	if x != nil {
+		return x.Address6
+	}
+	return nil
+}
+
+// 节点需要连接的节点
+type RemotePeer struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
+	// 公钥
+	Pubkey string `protobuf:"bytes,2,opt,name=pubkey,proto3" json:"pubkey,omitempty"`
+	// 端点信息，为空时等待对方主动连接
+	Endpoint string `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	// 经过该节点转发的地址
+	AllowedIps []*CidrAddress `protobuf:"bytes,4,rep,name=allowed_ips,json=allowedIps,proto3" json:"allowed_ips,omitempty"`
+	// 预共享密钥，没有使用时为空
+	PresharedKey string `protobuf:"bytes,5,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"`
+	// 保持心跳的时间间隔，单位秒
+	KeepAliveInterval int32 `protobuf:"varint,6,opt,name=keep_alive_interval,json=keepAliveInterval,proto3" json:"keep_alive_interval,omitempty"`
+	// 是否为中继节点，中继节点按优先级排序，只有当前使用的中继节点有 allowed_ips
+	IsRelay bool `protobuf:"varint,7,opt,name=is_relay,json=isRelay,proto3" json:"is_relay,omitempty"`
+}
+
+func (x *RemotePeer) Reset() {
+	*x = RemotePeer{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RemotePeer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemotePeer) ProtoMessage() {}
+
+func (x *RemotePeer) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemotePeer.ProtoReflect.Descriptor instead.
+func (*RemotePeer) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RemotePeer) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
+func (x *RemotePeer) GetPubkey() string {
+	if x != nil {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *RemotePeer) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *RemotePeer) GetAllowedIps() []*CidrAddress {
+	if x != nil {
+		return x.AllowedIps
+	}
+	return nil
+}
+
+func (x *RemotePeer) GetPresharedKey() string {
+	if x != nil {
+		return x.PresharedKey
+	}
+	return ""
+}
+
+func (x *RemotePeer) GetKeepAliveInterval() int32 {
+	if x != nil {
+		return x.KeepAliveInterval
+	}
+	return 0
+}
+
+func (x *RemotePeer) GetIsRelay() bool {
+	if x != nil {
+		return x.IsRelay
+	}
+	return false
+}
+
+// 节点切换当前使用的中继节点
+type SwitchRelayReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
+	// 切换到的中继节点名，必须是节点注册时指定的中继节点之一
+	RelayName string `protobuf:"bytes,2,opt,name=relay_name,json=relayName,proto3" json:"relay_name,omitempty"`
+}
+
+func (x *SwitchRelayReq) Reset() {
+	*x = SwitchRelayReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SwitchRelayReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SwitchRelayReq) ProtoMessage() {}
+
+func (x *SwitchRelayReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SwitchRelayReq.ProtoReflect.Descriptor instead.
+func (*SwitchRelayReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *SwitchRelayReq) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
+func (x *SwitchRelayReq) GetRelayName() string {
+	if x != nil {
+		return x.RelayName
+	}
+	return ""
+}
+
+// 节点续租地址
+type RenewLeaseReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 节点名
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3" json:"peer_name,omitempty"`
+}
+
+func (x *RenewLeaseReq) Reset() {
+	*x = RenewLeaseReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenewLeaseReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewLeaseReq) ProtoMessage() {}
+
+func (x *RenewLeaseReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewLeaseReq.ProtoReflect.Descriptor instead.
+func (*RenewLeaseReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *RenewLeaseReq) GetPeerName() string {
+	if x != nil {
+		return x.PeerName
+	}
+	return ""
+}
+
+type RenewLeaseRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 地址租约到期的 unix 时间戳，单位秒，为 0 时不会过期
+	ExpireTime int64 `protobuf:"varint,1,opt,name=expire_time,json=expireTime,proto3" json:"expire_time,omitempty"`
+}
+
+func (x *RenewLeaseRsp) Reset() {
+	*x = RenewLeaseRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[17]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RenewLeaseRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewLeaseRsp) ProtoMessage() {}
+
+func (x *RenewLeaseRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[17]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewLeaseRsp.ProtoReflect.Descriptor instead.
+func (*RenewLeaseRsp) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *RenewLeaseRsp) GetExpireTime() int64 {
+	if x != nil {
+		return x.ExpireTime
+	}
+	return 0
+}
+
+// 地址池中预留给指定节点的地址，节点注册时总是使用该地址
+type Reservation struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 通过节点名、公钥或者 MAC 地址中的一个指定节点
+	//
+	// Types that are assignable to Key:
+	//	*Reservation_PeerName
+	//	*Reservation_Pubkey
+	//	*Reservation_HardwareAddr
+	Key isReservation_Key `protobuf_oneof:"key"`
+	// 预留的地址，例如 192.168.222.10，IPv6 地址在 IPv6 地址池中预留
+	Address string `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
+	// 备注
+	Remark string `protobuf:"bytes,5,opt,name=remark,proto3" json:"remark,omitempty"`
+}
+
+func (x *Reservation) Reset() {
+	*x = Reservation{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[18]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Reservation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reservation) ProtoMessage() {}
+
+func (x *Reservation) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[18]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reservation.ProtoReflect.Descriptor instead.
+func (*Reservation) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{18}
+}
+
+func (m *Reservation) GetKey() isReservation_Key {
+	if m != nil {
+		return m.Key
+	}
+	return nil
+}
+
+func (x *Reservation) GetPeerName() string {
+	if x, ok := x.GetKey().(*Reservation_PeerName); ok {
+		return x.PeerName
+	}
+	return ""
+}
+
+func (x *Reservation) GetPubkey() string {
+	if x, ok := x.GetKey().(*Reservation_Pubkey); ok {
+		return x.Pubkey
+	}
+	return ""
+}
+
+func (x *Reservation) GetHardwareAddr() string {
+	if x, ok := x.GetKey().(*Reservation_HardwareAddr); ok {
+		return x.HardwareAddr
+	}
+	return ""
+}
+
+func (x *Reservation) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Reservation) GetRemark() string {
+	if x != nil {
+		return x.Remark
+	}
+	return ""
+}
+
+type isReservation_Key interface {
+	isReservation_Key()
+}
+
+type Reservation_PeerName struct {
+	PeerName string `protobuf:"bytes,1,opt,name=peer_name,json=peerName,proto3,oneof"`
+}
+
+type Reservation_Pubkey struct {
+	Pubkey string `protobuf:"bytes,2,opt,name=pubkey,proto3,oneof"`
+}
+
+type Reservation_HardwareAddr struct {
+	HardwareAddr string `protobuf:"bytes,3,opt,name=hardware_addr,json=hardwareAddr,proto3,oneof"`
+}
+
+func (*Reservation_PeerName) isReservation_Key() {}
+
+func (*Reservation_Pubkey) isReservation_Key() {}
+
+func (*Reservation_HardwareAddr) isReservation_Key() {}
+
+// 删除预留的地址
+type DeleteReservationReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 预留的地址
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *DeleteReservationReq) Reset() {
+	*x = DeleteReservationReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[19]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteReservationReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteReservationReq) ProtoMessage() {}
+
+func (x *DeleteReservationReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[19]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteReservationReq.ProtoReflect.Descriptor instead.
+func (*DeleteReservationReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *DeleteReservationReq) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type ListReservationsReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListReservationsReq) Reset() {
+	*x = ListReservationsReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[20]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListReservationsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsReq) ProtoMessage() {}
+
+func (x *ListReservationsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[20]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsReq.ProtoReflect.Descriptor instead.
+func (*ListReservationsReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{20}
+}
+
+type ListReservationsRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// IPv4 以及 IPv6 地址池中所有预留的地址
+	Reservations []*Reservation `protobuf:"bytes,1,rep,name=reservations,proto3" json:"reservations,omitempty"`
+}
+
+func (x *ListReservationsRsp) Reset() {
+	*x = ListReservationsRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[21]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListReservationsRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListReservationsRsp) ProtoMessage() {}
+
+func (x *ListReservationsRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListReservationsRsp.ProtoReflect.Descriptor instead.
+func (*ListReservationsRsp) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListReservationsRsp) GetReservations() []*Reservation {
+	if x != nil {
+		return x.Reservations
+	}
+	return nil
+}
+
+type GetPoolStatsReq struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetPoolStatsReq) Reset() {
+	*x = GetPoolStatsReq{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPoolStatsReq) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPoolStatsReq) ProtoMessage() {}
+
+func (x *GetPoolStatsReq) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPoolStatsReq.ProtoReflect.Descriptor instead.
+func (*GetPoolStatsReq) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{22}
+}
+
+// 地址池的使用情况，地址数量超过 uint64 时为 uint64 的最大值
+type PoolStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// 地址池所在的网络
+	Network string `protobuf:"bytes,1,opt,name=network,proto3" json:"network,omitempty"`
+	// 第一个可以分配的地址
+	First string `protobuf:"bytes,2,opt,name=first,proto3" json:"first,omitempty"`
+	// 最后一个可以分配的地址
+	Last string `protobuf:"bytes,3,opt,name=last,proto3" json:"last,omitempty"`
+	// 可以分配的地址数量，不包括排除的地址
+	Total uint64 `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	// 已经分配给节点的地址数量
+	Used uint64 `protobuf:"varint,5,opt,name=used,proto3" json:"used,omitempty"`
+	// 预留并且还没有被使用的地址数量
+	Reserved uint64 `protobuf:"varint,6,opt,name=reserved,proto3" json:"reserved,omitempty"`
+	// 还可以分配给其他节点的地址数量
+	Free uint64 `protobuf:"varint,7,opt,name=free,proto3" json:"free,omitempty"`
+}
+
+func (x *PoolStats) Reset() {
+	*x = PoolStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PoolStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PoolStats) ProtoMessage() {}
+
+func (x *PoolStats) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PoolStats.ProtoReflect.Descriptor instead.
+func (*PoolStats) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *PoolStats) GetNetwork() string {
+	if x != nil {
+		return x.Network
+	}
+	return ""
+}
+
+func (x *PoolStats) GetFirst() string {
+	if x != nil {
+		return x.First
+	}
+	return ""
+}
+
+func (x *PoolStats) GetLast() string {
+	if x != nil {
+		return x.Last
+	}
+	return ""
+}
+
+func (x *PoolStats) GetTotal() uint64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *PoolStats) GetUsed() uint64 {
+	if x != nil {
+		return x.Used
+	}
+	return 0
+}
+
+func (x *PoolStats) GetReserved() uint64 {
+	if x != nil {
+		return x.Reserved
+	}
+	return 0
+}
+
+func (x *PoolStats) GetFree() uint64 {
+	if x != nil {
+		return x.Free
+	}
+	return 0
+}
+
+type GetPoolStatsRsp struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// IPv4 以及 IPv6 地址池的使用情况
+	Pools []*PoolStats `protobuf:"bytes,1,rep,name=pools,proto3" json:"pools,omitempty"`
+}
+
+func (x *GetPoolStatsRsp) Reset() {
+	*x = GetPoolStatsRsp{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protocols_wg_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetPoolStatsRsp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPoolStatsRsp) ProtoMessage() {}
+
+func (x *GetPoolStatsRsp) ProtoReflect() protoreflect.Message {
+	mi := &file_protocols_wg_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPoolStatsRsp.ProtoReflect.Descriptor instead.
+func (*GetPoolStatsRsp) Descriptor() ([]byte, []int) {
+	return file_protocols_wg_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *GetPoolStatsRsp) GetPools() []*PoolStats {
+	if x != nil {
+		return x.Pools
+	}
+	return nil
+}
+
+var File_protocols_wg_proto protoreflect.FileDescriptor
+
+var file_protocols_wg_proto_rawDesc = []byte{
+	0x0a, 0x12, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x73, 0x2f, 0x77, 0x67, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x22, 0x0a,
+	0x0a, 0x08, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x52, 0x73, 0x70, 0x22, 0xaa, 0x03, 0x0a, 0x0f, 0x52,
+	0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1b,
+	0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x70,
+	0x65, 0x65, 0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79,
+	0x70, 0x65, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x30, 0x0a, 0x08,
+	0x73, 0x75, 0x62, 0x5f, 0x6e, 0x65, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x73, 0x75, 0x62, 0x4e, 0x65, 0x74, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72, 0x65, 0x73, 0x68, 0x61,
+	0x72, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0c, 0x70,
+	0x72, 0x65, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6d,
+	0x65, 0x73, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6d, 0x65, 0x73, 0x68, 0x12,
+	0x1a, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x72,
+	0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09,
+	0x52, 0x0a, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74,
+	0x61, 0x74, 0x69, 0x63, 0x12, 0x23, 0x0a, 0x0d, 0x68, 0x61, 0x72, 0x64, 0x77, 0x61, 0x72, 0x65,
+	0x5f, 0x61, 0x64, 0x64, 0x72, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x68, 0x61, 0x72,
+	0x64, 0x77, 0x61, 0x72, 0x65, 0x41, 0x64, 0x64, 0x72, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x6c, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x73, 0x75, 0x62, 0x6e, 0x65, 0x74, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x53, 0x75, 0x62, 0x6e,
+	0x65, 0x74, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x75, 0x62, 0x6e, 0x65, 0x74, 0x5f, 0x70, 0x72, 0x65,
+	0x66, 0x69, 0x78, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x73, 0x75, 0x62, 0x6e, 0x65,
+	0x74, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x22, 0xb9, 0x02, 0x0a, 0x0f, 0x52, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x73, 0x70, 0x12, 0x16, 0x0a, 0x06, 0x70,
+	0x75, 0x62, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x75, 0x62,
+	0x6b, 0x65, 0x79, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x72, 0x69, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x72, 0x69, 0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a, 0x07, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x3f, 0x0a, 0x0f,
+	0x72, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x50, 0x65, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0d,
+	0x72, 0x65, 0x6c, 0x61, 0x79, 0x50, 0x65, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x31, 0x0a,
+	0x08, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x36, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x08, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x36,
+	0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x54, 0x69, 0x6d,
+	0x65, 0x12, 0x30, 0x0a, 0x08, 0x73, 0x75, 0x62, 0x5f, 0x6e, 0x65, 0x74, 0x73, 0x18, 0x07, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43,
+	0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x73, 0x75, 0x62, 0x4e,
+	0x65, 0x74, 0x73, 0x22, 0x27, 0x0a, 0x0b, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x85, 0x01, 0x0a,
+	0x0d, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x50, 0x65, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1a,
+	0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x75,
+	0x62, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b,
+	0x65, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72, 0x65, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x5f,
+	0x6b, 0x65, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x65, 0x73, 0x68,
+	0x61, 0x72, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f,
+	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72,
+	0x4e, 0x61, 0x6d, 0x65, 0x22, 0x30, 0x0a, 0x11, 0x55, 0x6e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65, 0x65,
+	0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x65,
+	0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xda, 0x03, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x49,
+	0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65,
+	0x12, 0x25, 0x0a, 0x0e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x52, 0x08,
+	0x70, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x04, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x69, 0x73, 0x53,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x12, 0x2f, 0x0a,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x30,
+	0x0a, 0x08, 0x73, 0x75, 0x62, 0x5f, 0x6e, 0x65, 0x74, 0x73, 0x18, 0x07, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x73, 0x75, 0x62, 0x4e, 0x65, 0x74, 0x73,
+	0x12, 0x1a, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13,
+	0x6b, 0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76, 0x65, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x76, 0x61, 0x6c, 0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x6b, 0x65, 0x65, 0x70, 0x41,
+	0x6c, 0x69, 0x76, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x65, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72, 0x65,
+	0x6d, 0x61, 0x72, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x65, 0x73, 0x68, 0x18, 0x0b, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x04, 0x6d, 0x65, 0x73, 0x68, 0x12, 0x31, 0x0a, 0x08, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x36, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x52, 0x08, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x36, 0x12, 0x16, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x69, 0x63, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x73, 0x74, 0x61,
+	0x74, 0x69, 0x63, 0x22, 0xf3, 0x01, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x65, 0x65, 0x72,
+	0x73, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x73, 0x69, 0x7a,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x70, 0x61, 0x67, 0x65, 0x53, 0x69, 0x7a,
+	0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x70, 0x61, 0x67, 0x65, 0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x70, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e,
+	0x12, 0x25, 0x0a, 0x0e, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66, 0x61, 0x63, 0x65, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x66,
+	0x61, 0x63, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f,
+	0x74, 0x79, 0x70, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x52, 0x08,
+	0x70, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x12, 0x20, 0x0a, 0x09, 0x69, 0x73, 0x5f, 0x73,
+	0x65, 0x72, 0x76, 0x65, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x48, 0x00, 0x52, 0x08, 0x69,
+	0x73, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x0b, 0x6e, 0x61,
+	0x6d, 0x65, 0x5f, 0x70, 0x72, 0x65, 0x66, 0x69, 0x78, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0a, 0x6e, 0x61, 0x6d, 0x65, 0x50, 0x72, 0x65, 0x66, 0x69, 0x78, 0x42, 0x0c, 0x0a, 0x0a, 0x5f,
+	0x69, 0x73, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x22, 0x60, 0x0a, 0x0c, 0x4c, 0x69, 0x73,
+	0x74, 0x50, 0x65, 0x65, 0x72, 0x73, 0x52, 0x73, 0x70, 0x12, 0x28, 0x0a, 0x05, 0x70, 0x65, 0x65,
+	0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x05, 0x70, 0x65,
+	0x65, 0x72, 0x73, 0x12, 0x26, 0x0a, 0x0f, 0x6e, 0x65, 0x78, 0x74, 0x5f, 0x70, 0x61, 0x67, 0x65,
+	0x5f, 0x74, 0x6f, 0x6b, 0x65, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6e, 0x65,
+	0x78, 0x74, 0x50, 0x61, 0x67, 0x65, 0x54, 0x6f, 0x6b, 0x65, 0x6e, 0x22, 0x4c, 0x0a, 0x0a, 0x47,
+	0x65, 0x74, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1d, 0x0a, 0x09, 0x70, 0x65, 0x65,
+	0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x08,
+	0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x06, 0x70, 0x75, 0x62, 0x6b,
+	0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b,
+	0x65, 0x79, 0x42, 0x05, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x8e, 0x03, 0x0a, 0x0d, 0x55, 0x70,
+	0x64, 0x61, 0x74, 0x65, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70,
+	0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x34, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x48,
+	0x00, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70, 0x65, 0x88, 0x01, 0x01, 0x12, 0x2f,
+	0x0a, 0x08, 0x73, 0x75, 0x62, 0x5f, 0x6e, 0x65, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x75, 0x62, 0x6e,
+	0x65, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x07, 0x73, 0x75, 0x62, 0x4e, 0x65, 0x74, 0x73, 0x12,
+	0x33, 0x0a, 0x13, 0x6b, 0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76, 0x65, 0x5f, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x48, 0x01, 0x52, 0x11,
+	0x6b, 0x65, 0x65, 0x70, 0x41, 0x6c, 0x69, 0x76, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61,
+	0x6c, 0x88, 0x01, 0x01, 0x12, 0x1b, 0x0a, 0x06, 0x72, 0x65, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x02, 0x52, 0x06, 0x72, 0x65, 0x6d, 0x61, 0x72, 0x6b, 0x88, 0x01,
+	0x01, 0x12, 0x17, 0x0a, 0x04, 0x6d, 0x65, 0x73, 0x68, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x48,
+	0x03, 0x52, 0x04, 0x6d, 0x65, 0x73, 0x68, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08, 0x65, 0x6e,
+	0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x48, 0x04, 0x52, 0x08,
+	0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x88, 0x01, 0x01, 0x12, 0x1b, 0x0a, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x69, 0x63, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x48, 0x05, 0x52, 0x06, 0x73,
+	0x74, 0x61, 0x74, 0x69, 0x63, 0x88, 0x01, 0x01, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x70, 0x65, 0x65,
+	0x72, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x42, 0x16, 0x0a, 0x14, 0x5f, 0x6b, 0x65, 0x65, 0x70, 0x5f,
+	0x61, 0x6c, 0x69, 0x76, 0x65, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x42, 0x09,
+	0x0a, 0x07, 0x5f, 0x72, 0x65, 0x6d, 0x61, 0x72, 0x6b, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x6d, 0x65,
+	0x73, 0x68, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x42,
+	0x09, 0x0a, 0x07, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x22, 0x3e, 0x0a, 0x0a, 0x53, 0x75,
+	0x62, 0x6e, 0x65, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x08, 0x73, 0x75, 0x62, 0x5f,
+	0x6e, 0x65, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73,
+	0x73, 0x52, 0x07, 0x73, 0x75, 0x62, 0x4e, 0x65, 0x74, 0x73, 0x22, 0x2f, 0x0a, 0x10, 0x47, 0x65,
+	0x74, 0x50, 0x65, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x65, 0x71, 0x12, 0x1b,
+	0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x22, 0xc0, 0x01, 0x0a, 0x0d,
+	0x50, 0x65, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x73, 0x70, 0x12, 0x2f, 0x0a,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1f,
+	0x0a, 0x0b, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x5f, 0x70, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x0a, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x50, 0x6f, 0x72, 0x74, 0x12,
+	0x2a, 0x0a, 0x05, 0x70, 0x65, 0x65, 0x72, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65,
+	0x50, 0x65, 0x65, 0x72, 0x52, 0x05, 0x70, 0x65, 0x65, 0x72, 0x73, 0x12, 0x31, 0x0a, 0x08, 0x61,
+	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x36, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43, 0x69, 0x64, 0x72, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x52, 0x08, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x36, 0x22, 0x85,
+	0x02, 0x0a, 0x0a, 0x52, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x50, 0x65, 0x65, 0x72, 0x12, 0x1b, 0x0a,
+	0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x75,
+	0x62, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b,
+	0x65, 0x79, 0x12, 0x1a, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x65, 0x6e, 0x64, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x12, 0x36,
+	0x0a, 0x0b, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x69, 0x70, 0x73, 0x18, 0x04, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x43,
+	0x69, 0x64, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x0a, 0x61, 0x6c, 0x6c, 0x6f,
+	0x77, 0x65, 0x64, 0x49, 0x70, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72, 0x65, 0x73, 0x68, 0x61,
+	0x72, 0x65, 0x64, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70,
+	0x72, 0x65, 0x73, 0x68, 0x61, 0x72, 0x65, 0x64, 0x4b, 0x65, 0x79, 0x12, 0x2e, 0x0a, 0x13, 0x6b,
+	0x65, 0x65, 0x70, 0x5f, 0x61, 0x6c, 0x69, 0x76, 0x65, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76,
+	0x61, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x6b, 0x65, 0x65, 0x70, 0x41, 0x6c,
+	0x69, 0x76, 0x65, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x19, 0x0a, 0x08, 0x69,
+	0x73, 0x5f, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x69,
+	0x73, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x22, 0x4c, 0x0a, 0x0e, 0x53, 0x77, 0x69, 0x74, 0x63, 0x68,
+	0x52, 0x65, 0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72,
+	0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x65, 0x65,
+	0x72, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x5f, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x6c, 0x61, 0x79,
+	0x4e, 0x61, 0x6d, 0x65, 0x22, 0x2c, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x65, 0x77, 0x4c, 0x65, 0x61,
+	0x73, 0x65, 0x52, 0x65, 0x71, 0x12, 0x1b, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e, 0x61,
+	0x6d, 0x65, 0x22, 0x30, 0x0a, 0x0d, 0x52, 0x65, 0x6e, 0x65, 0x77, 0x4c, 0x65, 0x61, 0x73, 0x65,
+	0x52, 0x73, 0x70, 0x12, 0x1f, 0x0a, 0x0b, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65, 0x5f, 0x74, 0x69,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x65, 0x78, 0x70, 0x69, 0x72, 0x65,
+	0x54, 0x69, 0x6d, 0x65, 0x22, 0xa6, 0x01, 0x0a, 0x0b, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x09, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x6e, 0x61, 0x6d,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x08, 0x70, 0x65, 0x65, 0x72, 0x4e,
+	0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x06, 0x70, 0x75, 0x62, 0x6b, 0x65, 0x79, 0x12, 0x25, 0x0a,
+	0x0d, 0x68, 0x61, 0x72, 0x64, 0x77, 0x61, 0x72, 0x65, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0c, 0x68, 0x61, 0x72, 0x64, 0x77, 0x61, 0x72, 0x65,
+	0x41, 0x64, 0x64, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x16,
+	0x0a, 0x06, 0x72, 0x65, 0x6d, 0x61, 0x72, 0x6b, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x72, 0x65, 0x6d, 0x61, 0x72, 0x6b, 0x42, 0x05, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x30, 0x0a,
+	0x14, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22,
+	0x15, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x22, 0x50, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65,
+	0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x73, 0x70, 0x12, 0x39, 0x0a,
+	0x0c, 0x72, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52,
+	0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x72, 0x65, 0x73, 0x65,
+	0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0x11, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x50,
+	0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x22, 0xa9, 0x01, 0x0a, 0x09,
+	0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x6e, 0x65, 0x74,
+	0x77, 0x6f, 0x72, 0x6b, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x6e, 0x65, 0x74, 0x77,
+	0x6f, 0x72, 0x6b, 0x12, 0x14, 0x0a, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x05, 0x66, 0x69, 0x72, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x61, 0x73,
+	0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6c, 0x61, 0x73, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x74, 0x6f,
+	0x74, 0x61, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x75, 0x73, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x04, 0x75, 0x73, 0x65, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x72, 0x65, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x72, 0x65, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x04, 0x66, 0x72, 0x65, 0x65, 0x22, 0x3c, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x50, 0x6f,
+	0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x73, 0x70, 0x12, 0x29, 0x0a, 0x05, 0x70, 0x6f,
+	0x6f, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x05,
+	0x70, 0x6f, 0x6f, 0x6c, 0x73, 0x2a, 0x2c, 0x0a, 0x08, 0x50, 0x65, 0x65, 0x72, 0x54, 0x79, 0x70,
+	0x65, 0x12, 0x0b, 0x0a, 0x07, 0x55, 0x6e, 0x6b, 0x6e, 0x6f, 0x77, 0x6e, 0x10, 0x00, 0x12, 0x07,
+	0x0a, 0x03, 0x50, 0x32, 0x50, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x75, 0x62, 0x4e, 0x65,
+	0x74, 0x10, 0x02, 0x32, 0xc6, 0x06, 0x0a, 0x0d, 0x57, 0x69, 0x72, 0x65, 0x67, 0x75, 0x61, 0x72,
+	0x64, 0x54, 0x6f, 0x6f, 0x6c, 0x12, 0x46, 0x0a, 0x0c, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x50, 0x65, 0x65, 0x72, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71,
+	0x1a, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x73, 0x70, 0x22, 0x00, 0x12, 0x43, 0x0a,
+	0x0e, 0x55, 0x6e, 0x72, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x12,
+	0x1b, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x55, 0x6e, 0x72, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x65, 0x72, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x52, 0x73, 0x70,
+	0x22, 0x00, 0x12, 0x3d, 0x0a, 0x09, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x65, 0x65, 0x72, 0x73, 0x12,
+	0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50,
+	0x65, 0x65, 0x72, 0x73, 0x52, 0x65, 0x71, 0x1a, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63,
+	0x6f, 0x6c, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x50, 0x65, 0x65, 0x72, 0x73, 0x52, 0x73, 0x70, 0x22,
+	0x00, 0x12, 0x35, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x50, 0x65, 0x65, 0x72, 0x12, 0x14, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x65, 0x72, 0x52,
+	0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65,
+	0x65, 0x72, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x3b, 0x0a, 0x0a, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x50, 0x65, 0x65, 0x72, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x50, 0x65, 0x65, 0x72, 0x52, 0x65, 0x71, 0x1a,
+	0x12, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x49,
+	0x6e, 0x66, 0x6f, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x50, 0x65, 0x65, 0x72,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x1a, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x65, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52,
+	0x65, 0x71, 0x1a, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x50, 0x65,
+	0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x73, 0x70, 0x22, 0x00, 0x12, 0x42, 0x0a,
+	0x0b, 0x53, 0x77, 0x69, 0x74, 0x63, 0x68, 0x52, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x18, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x53, 0x77, 0x69, 0x74, 0x63, 0x68, 0x52, 0x65,
+	0x6c, 0x61, 0x79, 0x52, 0x65, 0x71, 0x1a, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f,
+	0x6c, 0x2e, 0x50, 0x65, 0x65, 0x72, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x73, 0x70, 0x22,
+	0x00, 0x12, 0x40, 0x0a, 0x0a, 0x52, 0x65, 0x6e, 0x65, 0x77, 0x4c, 0x65, 0x61, 0x73, 0x65, 0x12,
+	0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x6e, 0x65, 0x77,
+	0x4c, 0x65, 0x61, 0x73, 0x65, 0x52, 0x65, 0x71, 0x1a, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x6e, 0x65, 0x77, 0x4c, 0x65, 0x61, 0x73, 0x65, 0x52, 0x73,
+	0x70, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x0e, 0x41, 0x64, 0x64, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x15, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x2e, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x15, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x22, 0x00, 0x12, 0x49, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52,
+	0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1e, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x52, 0x65, 0x73, 0x65,
+	0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71, 0x1a, 0x12, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x52, 0x73, 0x70, 0x22, 0x00,
+	0x12, 0x52, 0x0a, 0x10, 0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
+	0x4c, 0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x1a, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x52, 0x65, 0x73, 0x65, 0x72, 0x76, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52,
+	0x73, 0x70, 0x22, 0x00, 0x12, 0x46, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x50, 0x6f, 0x6f, 0x6c, 0x53,
+	0x74, 0x61, 0x74, 0x73, 0x12, 0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e,
+	0x47, 0x65, 0x74, 0x50, 0x6f, 0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x1a,
+	0x19, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x2e, 0x47, 0x65, 0x74, 0x50, 0x6f,
+	0x6f, 0x6c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x73, 0x70, 0x22, 0x00, 0x42, 0x2e, 0x5a, 0x2c,
+	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6f, 0x6e, 0x65, 0x73, 0x61,
+	0x6c, 0x74, 0x65, 0x64, 0x73, 0x65, 0x61, 0x66, 0x69, 0x73, 0x68, 0x2f, 0x77, 0x67, 0x2d, 0x74,
+	0x6f, 0x6f, 0x6c, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protocols_wg_proto_rawDescOnce sync.Once
+	file_protocols_wg_proto_rawDescData = file_protocols_wg_proto_rawDesc
+)
+
+func file_protocols_wg_proto_rawDescGZIP() []byte {
+	file_protocols_wg_proto_rawDescOnce.Do(func() {
+		file_protocols_wg_proto_rawDescData = protoimpl.X.CompressGZIP(file_protocols_wg_proto_rawDescData)
+	})
+	return file_protocols_wg_proto_rawDescData
+}
+
+var file_protocols_wg_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_protocols_wg_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_protocols_wg_proto_goTypes = []interface{}{
+	(PeerType)(0),                // 0: protocol.PeerType
+	(*EmptyRsp)(nil),             // 1: protocol.EmptyRsp
+	(*RegisterPeerReq)(nil),      // 2: protocol.RegisterPeerReq
+	(*RegisterPeerRsp)(nil),      // 3: protocol.RegisterPeerRsp
+	(*CidrAddress)(nil),          // 4: protocol.CidrAddress
+	(*RelayPeerInfo)(nil),        // 5: protocol.RelayPeerInfo
+	(*UnregisterPeerReq)(nil),    // 6: protocol.UnregisterPeerReq
+	(*PeerInfo)(nil),             // 7: protocol.PeerInfo
+	(*ListPeersReq)(nil),         // 8: protocol.ListPeersReq
+	(*ListPeersRsp)(nil),         // 9: protocol.ListPeersRsp
+	(*GetPeerReq)(nil),           // 10: protocol.GetPeerReq
+	(*UpdatePeerReq)(nil),        // 11: protocol.UpdatePeerReq
+	(*SubnetList)(nil),           // 12: protocol.SubnetList
+	(*GetPeerConfigReq)(nil),     // 13: protocol.GetPeerConfigReq
+	(*PeerConfigRsp)(nil),        // 14: protocol.PeerConfigRsp
+	(*RemotePeer)(nil),           // 15: protocol.RemotePeer
+	(*SwitchRelayReq)(nil),       // 16: protocol.SwitchRelayReq
+	(*RenewLeaseReq)(nil),        // 17: protocol.RenewLeaseReq
+	(*RenewLeaseRsp)(nil),        // 18: protocol.RenewLeaseRsp
+	(*Reservation)(nil),          // 19: protocol.Reservation
+	(*DeleteReservationReq)(nil), // 20: protocol.DeleteReservationReq
+	(*ListReservationsReq)(nil),  // 21: protocol.ListReservationsReq
+	(*ListReservationsRsp)(nil),  // 22: protocol.ListReservationsRsp
+	(*GetPoolStatsReq)(nil),      // 23: protocol.GetPoolStatsReq
+	(*PoolStats)(nil),            // 24: protocol.PoolStats
+	(*GetPoolStatsRsp)(nil),      // 25: protocol.GetPoolStatsRsp
+}
+var file_protocols_wg_proto_depIdxs = []int32{
+	0,  // 0: protocol.RegisterPeerReq.peer_type:type_name -> protocol.PeerType
+	4,  // 1: protocol.RegisterPeerReq.sub_nets:type_name -> protocol.CidrAddress
+	4,  // 2: protocol.RegisterPeerRsp.address:type_name -> protocol.CidrAddress
+	5,  // 3: protocol.RegisterPeerRsp.relay_peer_info:type_name -> protocol.RelayPeerInfo
+	4,  // 4: protocol.RegisterPeerRsp.address6:type_name -> protocol.CidrAddress
+	4,  // 5: protocol.RegisterPeerRsp.sub_nets:type_name -> protocol.CidrAddress
+	0,  // 6: protocol.PeerInfo.peer_type:type_name -> protocol.PeerType
+	4,  // 7: protocol.PeerInfo.address:type_name -> protocol.CidrAddress
+	4,  // 8: protocol.PeerInfo.sub_nets:type_name -> protocol.CidrAddress
+	4,  // 9: protocol.PeerInfo.address6:type_name -> protocol.CidrAddress
+	0,  // 10: protocol.ListPeersReq.peer_type:type_name -> protocol.PeerType
+	7,  // 11: protocol.ListPeersRsp.peers:type_name -> protocol.PeerInfo
+	0,  // 12: protocol.UpdatePeerReq.peer_type:type_name -> protocol.PeerType
+	12, // 13: protocol.UpdatePeerReq.sub_nets:type_name -> protocol.SubnetList
+	4,  // 14: protocol.SubnetList.sub_nets:type_name -> protocol.CidrAddress
+	4,  // 15: protocol.PeerConfigRsp.address:type_name -> protocol.CidrAddress
+	15, // 16: protocol.PeerConfigRsp.peers:type_name -> protocol.RemotePeer
+	4,  // 17: protocol.PeerConfigRsp.address6:type_name -> protocol.CidrAddress
+	4,  // 18: protocol.RemotePeer.allowed_ips:type_name -> protocol.CidrAddress
+	19, // 19: protocol.ListReservationsRsp.reservations:type_name -> protocol.Reservation
+	24, // 20: protocol.GetPoolStatsRsp.pools:type_name -> protocol.PoolStats
+	2,  // 21: protocol.WireguardTool.RegisterPeer:input_type -> protocol.RegisterPeerReq
+	6,  // 22: protocol.WireguardTool.UnregisterPeer:input_type -> protocol.UnregisterPeerReq
+	8,  // 23: protocol.WireguardTool.ListPeers:input_type -> protocol.ListPeersReq
+	10, // 24: protocol.WireguardTool.GetPeer:input_type -> protocol.GetPeerReq
+	11, // 25: protocol.WireguardTool.UpdatePeer:input_type -> protocol.UpdatePeerReq
+	13, // 26: protocol.WireguardTool.GetPeerConfig:input_type -> protocol.GetPeerConfigReq
+	16, // 27: protocol.WireguardTool.SwitchRelay:input_type -> protocol.SwitchRelayReq
+	17, // 28: protocol.WireguardTool.RenewLease:input_type -> protocol.RenewLeaseReq
+	19, // 29: protocol.WireguardTool.AddReservation:input_type -> protocol.Reservation
+	20, // 30: protocol.WireguardTool.DeleteReservation:input_type -> protocol.DeleteReservationReq
+	21, // 31: protocol.WireguardTool.ListReservations:input_type -> protocol.ListReservationsReq
+	23, // 32: protocol.WireguardTool.GetPoolStats:input_type -> protocol.GetPoolStatsReq
+	3,  // 33: protocol.WireguardTool.RegisterPeer:output_type -> protocol.RegisterPeerRsp
+	1,  // 34: protocol.WireguardTool.UnregisterPeer:output_type -> protocol.EmptyRsp
+	9,  // 35: protocol.WireguardTool.ListPeers:output_type -> protocol.ListPeersRsp
+	7,  // 36: protocol.WireguardTool.GetPeer:output_type -> protocol.PeerInfo
+	7,  // 37: protocol.WireguardTool.UpdatePeer:output_type -> protocol.PeerInfo
+	14, // 38: protocol.WireguardTool.GetPeerConfig:output_type -> protocol.PeerConfigRsp
+	14, // 39: protocol.WireguardTool.SwitchRelay:output_type -> protocol.PeerConfigRsp
+	18, // 40: protocol.WireguardTool.RenewLease:output_type -> protocol.RenewLeaseRsp
+	19, // 41: protocol.WireguardTool.AddReservation:output_type -> protocol.Reservation
+	1,  // 42: protocol.WireguardTool.DeleteReservation:output_type -> protocol.EmptyRsp
+	22, // 43: protocol.WireguardTool.ListReservations:output_type -> protocol.ListReservationsRsp
+	25, // 44: protocol.WireguardTool.GetPoolStats:output_type -> protocol.GetPoolStatsRsp
+	33, // [33:45] is the sub-list for method output_type
+	21, // [21:33] is the sub-list for method input_type
+	21, // [21:21] is the sub-list for extension type_name
+	21, // [21:21] is the sub-list for extension extendee
+	0,  // [0:21] is the sub-list for field type_name
+}
+
+func init() { file_protocols_wg_proto_init() }
+func file_protocols_wg_proto_init() {
+	if File_protocols_wg_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protocols_wg_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*EmptyRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterPeerReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterPeerRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CidrAddress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
 				return nil
 			}
 		}
@@ -580,6 +2373,245 @@ func file_protocols_wg_proto_init() {
 				return nil
 			}
 		}
+		file_protocols_wg_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeerInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPeersReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListPeersRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPeerReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*UpdatePeerReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubnetList); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPeerConfigReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PeerConfigRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RemotePeer); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SwitchRelayReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenewLeaseReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RenewLeaseRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Reservation); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeleteReservationReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListReservationsReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ListReservationsRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPoolStatsReq); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PoolStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protocols_wg_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetPoolStatsRsp); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_protocols_wg_proto_msgTypes[7].OneofWrappers = []interface{}{}
+	file_protocols_wg_proto_msgTypes[9].OneofWrappers = []interface{}{
+		(*GetPeerReq_PeerName)(nil),
+		(*GetPeerReq_Pubkey)(nil),
+	}
+	file_protocols_wg_proto_msgTypes[10].OneofWrappers = []interface{}{}
+	file_protocols_wg_proto_msgTypes[18].OneofWrappers = []interface{}{
+		(*Reservation_PeerName)(nil),
+		(*Reservation_Pubkey)(nil),
+		(*Reservation_HardwareAddr)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -587,7 +2619,7 @@ func file_protocols_wg_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_protocols_wg_proto_rawDesc,
 			NumEnums:      1,
-			NumMessages:   6,
+			NumMessages:   25,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,8 +19,18 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	WireguardTool_RegisterPeer_FullMethodName   = "/protocol.WireguardTool/RegisterPeer"
-	WireguardTool_UnregisterPeer_FullMethodName = "/protocol.WireguardTool/UnregisterPeer"
+	WireguardTool_RegisterPeer_FullMethodName      = "/protocol.WireguardTool/RegisterPeer"
+	WireguardTool_UnregisterPeer_FullMethodName    = "/protocol.WireguardTool/UnregisterPeer"
+	WireguardTool_ListPeers_FullMethodName         = "/protocol.WireguardTool/ListPeers"
+	WireguardTool_GetPeer_FullMethodName           = "/protocol.WireguardTool/GetPeer"
+	WireguardTool_UpdatePeer_FullMethodName        = "/protocol.WireguardTool/UpdatePeer"
+	WireguardTool_GetPeerConfig_FullMethodName     = "/protocol.WireguardTool/GetPeerConfig"
+	WireguardTool_SwitchRelay_FullMethodName       = "/protocol.WireguardTool/SwitchRelay"
+	WireguardTool_RenewLease_FullMethodName        = "/protocol.WireguardTool/RenewLease"
+	WireguardTool_AddReservation_FullMethodName    = "/protocol.WireguardTool/AddReservation"
+	WireguardTool_DeleteReservation_FullMethodName = "/protocol.WireguardTool/DeleteReservation"
+	WireguardTool_ListReservations_FullMethodName  = "/protocol.WireguardTool/ListReservations"
+	WireguardTool_GetPoolStats_FullMethodName      = "/protocol.WireguardTool/GetPoolStats"
 )
 
 // WireguardToolClient is the client API for WireguardTool service.
@@ -29,6 +39,16 @@ const (
 type WireguardToolClient interface {
 	RegisterPeer(ctx context.Context, in *RegisterPeerReq, opts ...grpc.CallOption) (*RegisterPeerRsp, error)
 	UnregisterPeer(ctx context.Context, in *UnregisterPeerReq, opts ...grpc.CallOption) (*EmptyRsp, error)
+	ListPeers(ctx context.Context, in *ListPeersReq, opts ...grpc.CallOption) (*ListPeersRsp, error)
+	GetPeer(ctx context.Context, in *GetPeerReq, opts ...grpc.CallOption) (*PeerInfo, error)
+	UpdatePeer(ctx context.Context, in *UpdatePeerReq, opts ...grpc.CallOption) (*PeerInfo, error)
+	GetPeerConfig(ctx context.Context, in *GetPeerConfigReq, opts ...grpc.CallOption) (*PeerConfigRsp, error)
+	SwitchRelay(ctx context.Context, in *SwitchRelayReq, opts ...grpc.CallOption) (*PeerConfigRsp, error)
+	RenewLease(ctx context.Context, in *RenewLeaseReq, opts ...grpc.CallOption) (*RenewLeaseRsp, error)
+	AddReservation(ctx context.Context, in *Reservation, opts ...grpc.CallOption) (*Reservation, error)
+	DeleteReservation(ctx context.Context, in *DeleteReservationReq, opts ...grpc.CallOption) (*EmptyRsp, error)
+	ListReservations(ctx context.Context, in *ListReservationsReq, opts ...grpc.CallOption) (*ListReservationsRsp, error)
+	GetPoolStats(ctx context.Context, in *GetPoolStatsReq, opts ...grpc.CallOption) (*GetPoolStatsRsp, error)
 }
 
 type wireguardToolClient struct {
@@ -57,12 +77,112 @@ func (c *wireguardToolClient) UnregisterPeer(ctx context.Context, in *Unregister
 	return out, nil
 }
 
+func (c *wireguardToolClient) ListPeers(ctx context.Context, in *ListPeersReq, opts ...grpc.CallOption) (*ListPeersRsp, error) {
+	out := new(ListPeersRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_ListPeers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) GetPeer(ctx context.Context, in *GetPeerReq, opts ...grpc.CallOption) (*PeerInfo, error) {
+	out := new(PeerInfo)
+	err := c.cc.Invoke(ctx, WireguardTool_GetPeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) UpdatePeer(ctx context.Context, in *UpdatePeerReq, opts ...grpc.CallOption) (*PeerInfo, error) {
+	out := new(PeerInfo)
+	err := c.cc.Invoke(ctx, WireguardTool_UpdatePeer_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) GetPeerConfig(ctx context.Context, in *GetPeerConfigReq, opts ...grpc.CallOption) (*PeerConfigRsp, error) {
+	out := new(PeerConfigRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_GetPeerConfig_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) SwitchRelay(ctx context.Context, in *SwitchRelayReq, opts ...grpc.CallOption) (*PeerConfigRsp, error) {
+	out := new(PeerConfigRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_SwitchRelay_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) RenewLease(ctx context.Context, in *RenewLeaseReq, opts ...grpc.CallOption) (*RenewLeaseRsp, error) {
+	out := new(RenewLeaseRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_RenewLease_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) AddReservation(ctx context.Context, in *Reservation, opts ...grpc.CallOption) (*Reservation, error) {
+	out := new(Reservation)
+	err := c.cc.Invoke(ctx, WireguardTool_AddReservation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) DeleteReservation(ctx context.Context, in *DeleteReservationReq, opts ...grpc.CallOption) (*EmptyRsp, error) {
+	out := new(EmptyRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_DeleteReservation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) ListReservations(ctx context.Context, in *ListReservationsReq, opts ...grpc.CallOption) (*ListReservationsRsp, error) {
+	out := new(ListReservationsRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_ListReservations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *wireguardToolClient) GetPoolStats(ctx context.Context, in *GetPoolStatsReq, opts ...grpc.CallOption) (*GetPoolStatsRsp, error) {
+	out := new(GetPoolStatsRsp)
+	err := c.cc.Invoke(ctx, WireguardTool_GetPoolStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // WireguardToolServer is the server API for WireguardTool service.
 // All implementations must embed UnimplementedWireguardToolServer
 // for forward compatibility
 type WireguardToolServer interface {
 	RegisterPeer(context.Context, *RegisterPeerReq) (*RegisterPeerRsp, error)
 	UnregisterPeer(context.Context, *UnregisterPeerReq) (*EmptyRsp, error)
+	ListPeers(context.Context, *ListPeersReq) (*ListPeersRsp, error)
+	GetPeer(context.Context, *GetPeerReq) (*PeerInfo, error)
+	UpdatePeer(context.Context, *UpdatePeerReq) (*PeerInfo, error)
+	GetPeerConfig(context.Context, *GetPeerConfigReq) (*PeerConfigRsp, error)
+	SwitchRelay(context.Context, *SwitchRelayReq) (*PeerConfigRsp, error)
+	RenewLease(context.Context, *RenewLeaseReq) (*RenewLeaseRsp, error)
+	AddReservation(context.Context, *Reservation) (*Reservation, error)
+	DeleteReservation(context.Context, *DeleteReservationReq) (*EmptyRsp, error)
+	ListReservations(context.Context, *ListReservationsReq) (*ListReservationsRsp, error)
+	GetPoolStats(context.Context, *GetPoolStatsReq) (*GetPoolStatsRsp, error)
 	mustEmbedUnimplementedWireguardToolServer()
 }
 
@@ -76,6 +196,36 @@ func (UnimplementedWireguardToolServer) RegisterPeer(context.Context, *RegisterP
 func (UnimplementedWireguardToolServer) UnregisterPeer(context.Context, *UnregisterPeerReq) (*EmptyRsp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UnregisterPeer not implemented")
 }
+func (UnimplementedWireguardToolServer) ListPeers(context.Context, *ListPeersReq) (*ListPeersRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPeers not implemented")
+}
+func (UnimplementedWireguardToolServer) GetPeer(context.Context, *GetPeerReq) (*PeerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeer not implemented")
+}
+func (UnimplementedWireguardToolServer) UpdatePeer(context.Context, *UpdatePeerReq) (*PeerInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePeer not implemented")
+}
+func (UnimplementedWireguardToolServer) GetPeerConfig(context.Context, *GetPeerConfigReq) (*PeerConfigRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeerConfig not implemented")
+}
+func (UnimplementedWireguardToolServer) SwitchRelay(context.Context, *SwitchRelayReq) (*PeerConfigRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SwitchRelay not implemented")
+}
+func (UnimplementedWireguardToolServer) RenewLease(context.Context, *RenewLeaseReq) (*RenewLeaseRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewLease not implemented")
+}
+func (UnimplementedWireguardToolServer) AddReservation(context.Context, *Reservation) (*Reservation, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddReservation not implemented")
+}
+func (UnimplementedWireguardToolServer) DeleteReservation(context.Context, *DeleteReservationReq) (*EmptyRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteReservation not implemented")
+}
+func (UnimplementedWireguardToolServer) ListReservations(context.Context, *ListReservationsReq) (*ListReservationsRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReservations not implemented")
+}
+func (UnimplementedWireguardToolServer) GetPoolStats(context.Context, *GetPoolStatsReq) (*GetPoolStatsRsp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPoolStats not implemented")
+}
 func (UnimplementedWireguardToolServer) mustEmbedUnimplementedWireguardToolServer() {}
 
 // UnsafeWireguardToolServer may be embedded to opt out of forward compatibility for this service.
@@ -125,6 +275,186 @@ func _WireguardTool_UnregisterPeer_Handler(srv interface{}, ctx context.Context,
 	return interceptor(ctx, in, info, handler)
 }
 
+func _WireguardTool_ListPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPeersReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).ListPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_ListPeers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).ListPeers(ctx, req.(*ListPeersReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_GetPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPeerReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).GetPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_GetPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).GetPeer(ctx, req.(*GetPeerReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_UpdatePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePeerReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).UpdatePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_UpdatePeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).UpdatePeer(ctx, req.(*UpdatePeerReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_GetPeerConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPeerConfigReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).GetPeerConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_GetPeerConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).GetPeerConfig(ctx, req.(*GetPeerConfigReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_SwitchRelay_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwitchRelayReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).SwitchRelay(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_SwitchRelay_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).SwitchRelay(ctx, req.(*SwitchRelayReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_RenewLease_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewLeaseReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).RenewLease(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_RenewLease_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).RenewLease(ctx, req.(*RenewLeaseReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_AddReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Reservation)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).AddReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_AddReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).AddReservation(ctx, req.(*Reservation))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_DeleteReservation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteReservationReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).DeleteReservation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_DeleteReservation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).DeleteReservation(ctx, req.(*DeleteReservationReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_ListReservations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReservationsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).ListReservations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_ListReservations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).ListReservations(ctx, req.(*ListReservationsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _WireguardTool_GetPoolStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPoolStatsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WireguardToolServer).GetPoolStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WireguardTool_GetPoolStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WireguardToolServer).GetPoolStats(ctx, req.(*GetPoolStatsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // WireguardTool_ServiceDesc is the grpc.ServiceDesc for WireguardTool service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -140,6 +470,46 @@ var WireguardTool_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UnregisterPeer",
 			Handler:    _WireguardTool_UnregisterPeer_Handler,
 		},
+		{
+			MethodName: "ListPeers",
+			Handler:    _WireguardTool_ListPeers_Handler,
+		},
+		{
+			MethodName: "GetPeer",
+			Handler:    _WireguardTool_GetPeer_Handler,
+		},
+		{
+			MethodName: "UpdatePeer",
+			Handler:    _WireguardTool_UpdatePeer_Handler,
+		},
+		{
+			MethodName: "GetPeerConfig",
+			Handler:    _WireguardTool_GetPeerConfig_Handler,
+		},
+		{
+			MethodName: "SwitchRelay",
+			Handler:    _WireguardTool_SwitchRelay_Handler,
+		},
+		{
+			MethodName: "RenewLease",
+			Handler:    _WireguardTool_RenewLease_Handler,
+		},
+		{
+			MethodName: "AddReservation",
+			Handler:    _WireguardTool_AddReservation_Handler,
+		},
+		{
+			MethodName: "DeleteReservation",
+			Handler:    _WireguardTool_DeleteReservation_Handler,
+		},
+		{
+			MethodName: "ListReservations",
+			Handler:    _WireguardTool_ListReservations_Handler,
+		},
+		{
+			MethodName: "GetPoolStats",
+			Handler:    _WireguardTool_GetPoolStats_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "protocols/wg.proto",
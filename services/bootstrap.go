@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// NetworkConfig 中继节点以及所在网络的配置
+type NetworkConfig struct {
+	PeerName          string           // 中继节点名
+	InterfaceName     string           // wg 接口名
+	Address           inet.CidrAddress // 中继节点的地址，同时决定了整个网络的地址范围
+	Address6          inet.CidrAddress // 中继节点的 IPv6 地址，为空时不开启双栈
+	ListenPort        uint16           // 监听端口
+	PublicIp          string           // 公网 IP
+	KeepAliveInterval int              // 节点默认的保活时长，单位秒
+}
+
+// Bootstrap 根据配置启动本地的中继节点
+// 第一次启动时生成中继节点的密钥对并创建记录，之后每次启动都会启动 wg 接口并重新添加所有已经注册的节点
+// 启动之后只有本地的中继节点的 wg 设备会被修改
+// 要求使用预共享密钥时，为之前注册的没有预共享密钥的节点生成预共享密钥
+func (s *Server) Bootstrap(ctx context.Context, network NetworkConfig) error {
+	if !network.Address.IsIPv4() {
+		return fmt.Errorf("%w: %s 不是 IPv4 地址", errs.WgInvalidAddressError, network.Address.String())
+	}
+	if !network.Address6.IsZero() && network.Address6.IsIPv4() {
+		return fmt.Errorf("%w: %s 不是 IPv6 地址", errs.WgInvalidAddressError, network.Address6.String())
+	}
+
+	if err := models.CheckAllocateStrategy(s.strategy, network.Address.GetNetwork()); err != nil {
+		return err
+	}
+	if err := s.pool.Check(network.Address.GetNetwork()); err != nil {
+		return err
+	}
+	if !network.Address6.IsZero() {
+		if err := models.CheckAllocateStrategy(s.strategy6, network.Address6.GetNetwork()); err != nil {
+			return err
+		}
+		if err := s.pool6.Check(network.Address6.GetNetwork()); err != nil {
+			return err
+		}
+	}
+	if s.supernet.IP != nil {
+		if err := models.CheckSubnetPrefix(s.supernet, s.subnetSize); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var relay models.Peer
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where(&models.Peer{IsServer: true, PeerName: network.PeerName}).First(&relay).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err = checkNewRelay(tx, network); err != nil {
+				return err
+			}
+			relay, err = newRelayPeer(network)
+			if err != nil {
+				return err
+			}
+			s.logger.Info(ctx, "create relay peer", zap.String("peer", relay.PeerName), zap.String("address", relay.PeerAddress.String()),
+				zap.String("address6", relay.PeerAddress6.String()), zap.String("pubkey", relay.PublicKey))
+			return tx.Create(&relay).Error
+		}
+		if err != nil {
+			return err
+		}
+		// 地址变化后已经分配给节点的地址都会失效，不允许直接修改
+		if relay.PeerAddress.String() != network.Address.String() {
+			return fmt.Errorf("%w: %s != %s", errs.WgNetworkChangedError, relay.PeerAddress.String(), network.Address.String())
+		}
+		// 已有的网络可以开启双栈，开启之后同样不允许修改
+		if relay.PeerAddress6.String() != network.Address6.String() {
+			if !relay.PeerAddress6.IsZero() {
+				return fmt.Errorf("%w: %s != %s", errs.WgNetworkChangedError, relay.PeerAddress6.String(), network.Address6.String())
+			}
+			if err = checkRelayAddress(tx, network.PeerName, network.Address6, true); err != nil {
+				return err
+			}
+			relay.PeerAddress6 = network.Address6
+		}
+		relay.InterfaceName = network.InterfaceName
+		relay.PublicIp = network.PublicIp
+		relay.ListenPort = network.ListenPort
+		relay.KeepAliveInterval = network.KeepAliveInterval
+		return tx.Save(&relay).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if s.pskRequired {
+		if err = s.backfillPresharedKeys(ctx); err != nil {
+			return err
+		}
+	}
+
+	s.localRelays[relay.ID] = true
+	if err = s.operator.SetupInterface(relay.ToWgServerConfig()); err != nil {
+		return err
+	}
+	s.logger.Info(ctx, "setup relay interface", zap.String("interface", relay.InterfaceName),
+		zap.String("address", relay.PeerAddress.String()), zap.Uint16("port", relay.ListenPort))
+	// 重新添加所有已经注册的节点，设备上多余的节点由 Reconcile 删除
+	return s.addRelayPeers(ctx, relay)
+}
+
+// backfillPresharedKeys 为开启强制预共享密钥之前注册的节点生成预共享密钥
+// 节点需要通过 GetPeerConfig 重新获取配置，否则无法与中继节点握手
+func (s *Server) backfillPresharedKeys(ctx context.Context) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var peers []models.Peer
+		if err := tx.Where("is_server = ? AND (preshared_key = '' OR preshared_key IS NULL)", false).Find(&peers).Error; err != nil {
+			return err
+		}
+		for _, peer := range peers {
+			psk, err := wgtypes.GenerateKey()
+			if err != nil {
+				return err
+			}
+			if err = tx.Model(&peer).Update("preshared_key", psk.String()).Error; err != nil {
+				return err
+			}
+			s.logger.Warn(ctx, "generate preshared key for existing peer, peer needs to fetch its config again",
+				zap.String("peer", peer.PeerName))
+		}
+		return nil
+	})
+}
+
+// checkNewRelay 检查新的中继节点是否可以加入已有的网络
+// 所有的中继节点必须在同一个网络中，并且地址没有被分配给其他节点
+func checkNewRelay(tx *gorm.DB, network NetworkConfig) error {
+	if err := checkRelayAddress(tx, network.PeerName, network.Address, false); err != nil {
+		return err
+	}
+	return checkRelayAddress(tx, network.PeerName, network.Address6, true)
+}
+
+// checkRelayAddress 检查中继节点的地址是否在已有的网络中并且没有被分配给其他节点，检查通过后保留该地址
+// 网络没有开启双栈时只有第一个中继节点可以开启
+func checkRelayAddress(tx *gorm.DB, peerName string, address inet.CidrAddress, ipv6 bool) error {
+	storage, relays, err := addressPool(tx, ipv6)
+	if errors.Is(err, errs.WgNoRelayPeerError) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if storage == nil {
+		if address.IsZero() || relays[0].PeerName == peerName {
+			return nil
+		}
+		return fmt.Errorf("%w: 网络没有开启 IPv6", errs.WgNetworkChangedError)
+	}
+	if address.IsZero() {
+		return fmt.Errorf("%w: 网络已经开启 IPv6", errs.WgNetworkChangedError)
+	}
+	current, expected := poolAddress(relays[0], ipv6).GetNetwork(), address.GetNetwork()
+	if current.String() != expected.String() {
+		return fmt.Errorf("%w: %s != %s", errs.WgNetworkChangedError, current.String(), expected.String())
+	}
+	used, err := storage.IsUsed(address.GetAddress())
+	if err != nil {
+		return err
+	}
+	if used {
+		return fmt.Errorf("%w: %s 已经被使用", errs.WgInvalidAddressError, address.String())
+	}
+	return storage.SetAddressWithMAC(address.GetAddress(), models.PeerHardwareAddr(peerName))
+}
+
+// newRelayPeer 生成中继节点的记录
+func newRelayPeer(network NetworkConfig) (models.Peer, error) {
+	priKey, pubKey, err := wg.GenerateWgKeyPairs()
+	if err != nil {
+		return models.Peer{}, err
+	}
+	return models.Peer{
+		InterfaceName:     network.InterfaceName,
+		PublicIp:          network.PublicIp,
+		PeerName:          network.PeerName,
+		PeerAddress:       network.Address,
+		PeerAddress6:      network.Address6,
+		PeerType:          uint(pb.PeerType_P2P),
+		IsServer:          true,
+		ListenPort:        network.ListenPort,
+		PrivateKey:        priKey.String(),
+		PublicKey:         pubKey.String(),
+		KeepAliveInterval: network.KeepAliveInterval,
+		Remark:            "中继节点",
+	}, nil
+}
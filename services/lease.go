@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// RenewLease 节点续租地址，节点需要在租约到期之前周期性调用
+func (s *Server) RenewLease(ctx context.Context, req *pb.RenewLeaseReq) (*pb.RenewLeaseRsp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var expireTime *time.Time
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		peer, err := models.GetPeerByName(tx, req.PeerName)
+		if err != nil {
+			return err
+		}
+		if peer.IsServer {
+			return fmt.Errorf("%w: %s", errs.WgRelayPeerError, peer.PeerName)
+		}
+		expireTime, err = s.renewLease(tx, peer)
+		return err
+	})
+	if err != nil {
+		s.logger.Error(ctx, "renew lease failed", zap.String("peer", req.PeerName), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	s.logger.Debug(ctx, "renew lease", zap.String("peer", req.PeerName), zap.Int64("expire", unixTime(expireTime)))
+	return &pb.RenewLeaseRsp{ExpireTime: unixTime(expireTime)}, nil
+}
+
+// renewLease 续租节点所有的地址，返回最早的到期时间，节点的地址都不会过期时返回 nil
+func (s *Server) renewLease(tx *gorm.DB, peer models.Peer) (*time.Time, error) {
+	var expireTime *time.Time
+	for _, ipv6 := range []bool{false, true} {
+		address := poolAddress(peer, ipv6)
+		if address.IsZero() {
+			continue
+		}
+		storage, _, err := addressPool(tx, ipv6)
+		if err != nil {
+			return nil, err
+		}
+		if storage == nil {
+			continue
+		}
+		t, err := storage.WithLease(s.leaseDuration(peer, ipv6)).RenewAddress(address.GetAddress())
+		if err != nil {
+			return nil, err
+		}
+		if t != nil && (expireTime == nil || t.Before(*expireTime)) {
+			expireTime = t
+		}
+	}
+	return expireTime, nil
+}
+
+// leaseDuration 节点在地址池中的租约时长，静态节点的租约不会过期
+func (s *Server) leaseDuration(peer models.Peer, ipv6 bool) time.Duration {
+	if peer.Static {
+		return 0
+	}
+	if ipv6 {
+		return s.lease6
+	}
+	return s.lease
+}
+
+// RunLeaseReaper 每隔 interval 执行一次 ReapLeases，直到 ctx 结束
+// interval 不大于 0 时不会回收到期的租约
+func (s *Server) RunLeaseReaper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ReapLeases(ctx); err != nil {
+				s.logger.Error(ctx, "reap leases failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// ReapLeases 删除地址租约已经到期的节点，返回被删除的节点名
+// 节点的地址通过 DhcpStorage.ReleaseAddress 释放，同时从 wg 设备中删除节点，静态节点不会被删除
+func (s *Server) ReapLeases(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expired, err := s.expiredPeers(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	var reaped, pubKeys []string
+	for _, name := range expired {
+		peer, err := s.removePeer(ctx, name)
+		if err != nil {
+			s.logger.Error(ctx, "reap peer failed", zap.String("peer", name), zap.Error(err))
+			continue
+		}
+		s.logger.Info(ctx, "reap expired peer", zap.String("peer", name))
+		reaped = append(reaped, name)
+		pubKeys = append(pubKeys, peer.PublicKey)
+	}
+	if len(reaped) > 0 {
+		// 从备用的中继节点中删除节点，并删除节点子网的路由
+		s.reconcileAfterChange(ctx, pubKeys...)
+	}
+	return reaped, nil
+}
+
+// expiredPeers 获取地址租约已经到期的节点名，没有对应节点的地址直接释放
+func (s *Server) expiredPeers(ctx context.Context, now time.Time) ([]string, error) {
+	var names []string
+	for _, ipv6 := range []bool{false, true} {
+		storage, relays, err := addressPool(s.db, ipv6)
+		if errors.Is(err, errs.WgNoRelayPeerError) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if storage == nil {
+			continue
+		}
+		ips, err := storage.GetExpiredAddresses(now)
+		if err != nil {
+			return nil, err
+		}
+		network := poolAddress(relays[0], ipv6).GetNetwork()
+		column := lo.Ternary(ipv6, "address6", "address")
+		for _, ip := range ips {
+			address := inet.NewCidrAddress(ip, network)
+			var peers []models.Peer
+			if err = s.db.Where(column+" = ?", address.String()).Find(&peers).Error; err != nil {
+				return nil, err
+			}
+			if len(peers) == 0 {
+				if err = storage.ReleaseAddress(ip); err != nil {
+					return nil, err
+				}
+				s.logger.Info(ctx, "release expired address", zap.String("address", address.String()))
+				continue
+			}
+			for _, peer := range peers {
+				if !peer.IsServer && !peer.Static {
+					names = append(names, peer.PeerName)
+				}
+			}
+		}
+	}
+	return lo.Uniq(names), nil
+}
+
+// unixTime 转换为 unix 时间戳，为空时返回 0
+func unixTime(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
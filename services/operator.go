@@ -0,0 +1,74 @@
+package services
+
+import (
+	"net"
+
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+var _ WgOperator = KernelOperator{}
+
+// WgOperator 定义服务端对 wg 设备的操作
+// 默认直接操作内核中的 wg 设备，测试时可以替换
+type WgOperator interface {
+	// SetupInterface 启动 wg 接口
+	SetupInterface(config wg.WgServerConfig) error
+	// AddPeer 在 wg 设备上添加节点
+	AddPeer(config wg.WgPeerConfig) error
+	// UpdatePeer 修改 wg 设备上已经存在的节点，AllowedIPs 会被完全替换
+	UpdatePeer(config wg.WgPeerConfig) error
+	// RemovePeer 从 wg 设备上删除节点
+	RemovePeer(interfaceName string, publicKey wgtypes.Key) error
+	// GetPeers 获取 wg 设备上所有的节点
+	GetPeers(interfaceName string) ([]wgtypes.Peer, error)
+	// AddRoutes 添加经过 wg 接口的路由
+	AddRoutes(config wg.RouteConfig, networks []net.IPNet) error
+	// DelRoutes 删除经过 wg 接口的路由
+	DelRoutes(config wg.RouteConfig, networks []net.IPNet) error
+	// GetRoutes 获取 wg-tool 在 wg 接口上添加的路由
+	GetRoutes(config wg.RouteConfig) ([]net.IPNet, error)
+}
+
+// KernelOperator 操作内核中的 wg 设备
+type KernelOperator struct{}
+
+// SetupInterface 启动 wg 接口
+func (KernelOperator) SetupInterface(config wg.WgServerConfig) error {
+	return wg.SetupWireguardInterface(config)
+}
+
+// AddPeer 在 wg 设备上添加节点
+func (KernelOperator) AddPeer(config wg.WgPeerConfig) error {
+	return wg.AddWgPeer(config)
+}
+
+// UpdatePeer 修改 wg 设备上已经存在的节点
+func (KernelOperator) UpdatePeer(config wg.WgPeerConfig) error {
+	return wg.UpdateWgPeer(config)
+}
+
+// RemovePeer 从 wg 设备上删除节点
+func (KernelOperator) RemovePeer(interfaceName string, publicKey wgtypes.Key) error {
+	return wg.RemoveWgPeer(interfaceName, publicKey)
+}
+
+// GetPeers 获取 wg 设备上所有的节点
+func (KernelOperator) GetPeers(interfaceName string) ([]wgtypes.Peer, error) {
+	return wg.GetWgPeers(interfaceName)
+}
+
+// AddRoutes 添加经过 wg 接口的路由
+func (KernelOperator) AddRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	return wg.AddRoutes(config, networks)
+}
+
+// DelRoutes 删除经过 wg 接口的路由
+func (KernelOperator) DelRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	return wg.DelRoutes(config, networks)
+}
+
+// GetRoutes 获取 wg-tool 在 wg 接口上添加的路由
+func (KernelOperator) GetRoutes(config wg.RouteConfig) ([]net.IPNet, error) {
+	return wg.GetRoutes(config)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// GetPeerConfig 获取节点 wg 设备需要的配置
+// 根据网络拓扑返回中继节点以及所有直接连接的节点
+func (s *Server) GetPeerConfig(ctx context.Context, req *pb.GetPeerConfigReq) (*pb.PeerConfigRsp, error) {
+	peer, err := models.GetPeerByName(s.db.WithContext(ctx), req.PeerName)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if peer.IsServer {
+		return nil, toStatusError(errs.WgRelayPeerError)
+	}
+	rsp, err := s.peerConfig(ctx, peer)
+	if err != nil {
+		s.logger.Error(ctx, "get peer config failed", zap.String("peer", req.PeerName), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	return rsp, nil
+}
+
+// SwitchRelay 节点切换当前使用的中继节点
+// 节点发现当前的中继节点不可用并在本地切换之后调用，中继节点之间根据新的归属转发节点的流量
+func (s *Server) SwitchRelay(ctx context.Context, req *pb.SwitchRelayReq) (*pb.PeerConfigRsp, error) {
+	s.mu.Lock()
+	var peer models.Peer
+	var switched bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if peer, err = models.GetPeerByName(tx, req.PeerName); err != nil {
+			return err
+		}
+		if peer.IsServer {
+			return fmt.Errorf("%w: %s", errs.WgRelayPeerError, peer.PeerName)
+		}
+		relays, err := peer.GetRelays(tx)
+		if err != nil {
+			return err
+		}
+		relay, ok := lo.Find(relays, func(item models.Peer) bool {
+			return item.PeerName == req.RelayName
+		})
+		if !ok {
+			return fmt.Errorf("%w: %s", errs.WgInvalidRelayError, req.RelayName)
+		}
+		if peer.ConnectTo == relay.ID {
+			return nil
+		}
+		peer.ConnectTo = relay.ID
+		switched = true
+		return tx.Save(&peer).Error
+	})
+	if err == nil && switched {
+		s.logger.Info(ctx, "switch relay", zap.String("peer", peer.PeerName), zap.String("relay", req.RelayName))
+		s.reconcileAfterChange(ctx, peer.PublicKey)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		s.logger.Error(ctx, "switch relay failed", zap.String("peer", req.PeerName), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	rsp, err := s.peerConfig(ctx, peer)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return rsp, nil
+}
+
+// peerConfig 生成节点 wg 设备需要的配置
+func (s *Server) peerConfig(ctx context.Context, peer models.Peer) (*pb.PeerConfigRsp, error) {
+	db := s.db.WithContext(ctx)
+	configs, err := peer.ToWgPeerConfigs(db, s.topology, s.pskRequired)
+	if err != nil {
+		return nil, err
+	}
+	// 根据公钥找到对应的节点名
+	pubKeys := lo.Map(configs, func(item wg.WgPeerConfig, _ int) string {
+		return item.PeerConfig.PublicKey.String()
+	})
+	var remotes []models.Peer
+	if err = db.Where("public_key IN ?", pubKeys).Find(&remotes).Error; err != nil {
+		return nil, err
+	}
+	remoteMap := lo.SliceToMap(remotes, func(item models.Peer) (string, models.Peer) {
+		return item.PublicKey, item
+	})
+
+	rsp := &pb.PeerConfigRsp{
+		Address:    &pb.CidrAddress{Address: peer.PeerAddress.String()},
+		Address6:   toPbAddress(peer.PeerAddress6),
+		ListenPort: int32(peer.ListenPort),
+	}
+	for _, config := range configs {
+		rsp.Peers = append(rsp.Peers, toRemotePeer(config.PeerConfig, remoteMap[config.PeerConfig.PublicKey.String()]))
+	}
+	return rsp, nil
+}
+
+// toRemotePeer 将 wg 的节点配置转换为返回给节点的信息
+func toRemotePeer(config wgtypes.PeerConfig, peer models.Peer) *pb.RemotePeer {
+	remote := &pb.RemotePeer{
+		PeerName: peer.PeerName,
+		Pubkey:   config.PublicKey.String(),
+		IsRelay:  peer.IsServer,
+	}
+	remote.AllowedIps = lo.Map(config.AllowedIPs, func(item net.IPNet, _ int) *pb.CidrAddress {
+		return &pb.CidrAddress{Address: item.String()}
+	})
+	if config.Endpoint != nil {
+		remote.Endpoint = config.Endpoint.String()
+	}
+	if config.PresharedKey != nil {
+		remote.PresharedKey = config.PresharedKey.String()
+	}
+	if config.PersistentKeepaliveInterval != nil {
+		remote.KeepAliveInterval = int32(config.PersistentKeepaliveInterval.Seconds())
+	}
+	return remote
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+)
+
+// GetPoolStats 获取 IPv4 以及 IPv6 地址池的使用情况，只统计地址池范围中没有被排除的地址
+func (s *Server) GetPoolStats(ctx context.Context, req *pb.GetPoolStatsReq) (*pb.GetPoolStatsRsp, error) {
+	rsp := &pb.GetPoolStatsRsp{}
+	for _, ipv6 := range []bool{false, true} {
+		storage, _, err := addressPool(s.db, ipv6)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		if storage == nil {
+			continue
+		}
+		stats, err := storage.WithPool(s.poolConfig(ipv6)).Stats()
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		rsp.Pools = append(rsp.Pools, toPbPoolStats(stats))
+	}
+	return rsp, nil
+}
+
+// toPbPoolStats 转换为返回的地址池使用情况
+func toPbPoolStats(stats models.PoolStats) *pb.PoolStats {
+	return &pb.PoolStats{
+		Network:  stats.Network.String(),
+		First:    stats.First.String(),
+		Last:     stats.Last.String(),
+		Total:    stats.Total,
+		Used:     stats.Used,
+		Reserved: stats.Reserved,
+		Free:     stats.Free,
+	}
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
+)
+
+const (
+	defaultPageSize = 50  // 默认每页的数量
+	maxPageSize     = 500 // 每页的最大数量
+)
+
+// ListPeers 分页查询节点
+func (s *Server) ListPeers(ctx context.Context, req *pb.ListPeersReq) (*pb.ListPeersRsp, error) {
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	pageSize = min(pageSize, maxPageSize)
+	lastId, err := decodePageToken(req.PageToken)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	tx := s.db.WithContext(ctx).Model(&models.Peer{}).Where("id > ?", lastId)
+	if req.InterfaceName != "" {
+		tx = tx.Where("interface_name = ?", req.InterfaceName)
+	}
+	if req.PeerType != pb.PeerType_Unknown {
+		tx = tx.Where("type = ?", uint(req.PeerType))
+	}
+	if req.IsServer != nil {
+		tx = tx.Where("is_server = ?", req.GetIsServer())
+	}
+	if req.NamePrefix != "" {
+		tx = tx.Where(`peer_name LIKE ? ESCAPE '\'`, escapeLike(req.NamePrefix)+"%")
+	}
+	// 多查询一条记录，用来判断是否还有下一页
+	var peers []models.Peer
+	if err = tx.Order("id").Limit(pageSize + 1).Find(&peers).Error; err != nil {
+		return nil, toStatusError(err)
+	}
+
+	rsp := &pb.ListPeersRsp{}
+	if len(peers) > pageSize {
+		peers = peers[:pageSize]
+		rsp.NextPageToken = encodePageToken(peers[pageSize-1].ID)
+	}
+	rsp.Peers = lo.Map(peers, func(item models.Peer, _ int) *pb.PeerInfo {
+		return toPeerInfo(item)
+	})
+	return rsp, nil
+}
+
+// GetPeer 按节点名或者公钥查询节点
+func (s *Server) GetPeer(ctx context.Context, req *pb.GetPeerReq) (*pb.PeerInfo, error) {
+	var peer models.Peer
+	var err error
+	db := s.db.WithContext(ctx)
+	switch key := req.Key.(type) {
+	case *pb.GetPeerReq_PeerName:
+		peer, err = models.GetPeerByName(db, key.PeerName)
+	case *pb.GetPeerReq_Pubkey:
+		peer, err = models.GetPeerByPublicKey(db, key.Pubkey)
+	default:
+		err = fmt.Errorf("%w: 需要指定节点名或者公钥", errs.WgInvalidPeerNameError)
+	}
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	return toPeerInfo(peer), nil
+}
+
+// toPeerInfo 将数据库中的记录转换为节点信息，不包含私钥
+func toPeerInfo(peer models.Peer) *pb.PeerInfo {
+	info := &pb.PeerInfo{
+		PeerName:          peer.PeerName,
+		InterfaceName:     peer.InterfaceName,
+		PeerType:          pb.PeerType(peer.PeerType),
+		IsServer:          peer.IsServer,
+		Pubkey:            peer.PublicKey,
+		Address:           &pb.CidrAddress{Address: peer.PeerAddress.String()},
+		Address6:          toPbAddress(peer.PeerAddress6),
+		KeepAliveInterval: int32(peer.KeepAliveInterval),
+		Remark:            peer.Remark,
+		Mesh:              peer.Mesh,
+		Static:            peer.Static,
+	}
+	info.SubNets = toPbSubnets(peer.PeerSubnetAddress)
+	if endpoint, err := peer.GetEndpoint(); err == nil {
+		info.Endpoint = endpoint.String()
+	}
+	return info
+}
+
+// toPbAddress 转换为返回的地址，空地址返回 nil
+func toPbAddress(address inet.CidrAddress) *pb.CidrAddress {
+	if address.IsZero() {
+		return nil
+	}
+	return &pb.CidrAddress{Address: address.String()}
+}
+
+// toPbSubnets 转换为返回的子网地址
+func toPbSubnets(subnets inet.SubnetAddresses) []*pb.CidrAddress {
+	return lo.Map(subnets.GetAddresses(), func(item inet.CidrAddress, _ int) *pb.CidrAddress {
+		return &pb.CidrAddress{Address: item.String()}
+	})
+}
+
+// encodePageToken 使用上一页最后一条记录的 ID 作为分页 token
+func encodePageToken(lastId uint) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(uint64(lastId), 10)))
+}
+
+// decodePageToken 解析分页 token，为空时从头开始
+func decodePageToken(token string) (uint, error) {
+	if token == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errs.InvalidPageTokenError, token)
+	}
+	id, err := strconv.ParseUint(string(data), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errs.InvalidPageTokenError, token)
+	}
+	return uint(id), nil
+}
+
+// escapeLike 转义 LIKE 中的通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
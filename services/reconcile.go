@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+)
+
+// Reconcile 对比数据库中的节点与本地中继节点 wg 设备上的节点，并修正设备上的差异
+// dryRun 为 true 时只输出需要修正的内容，不修改设备
+// 返回每个中继节点 wg 设备上的差异
+func (s *Server) Reconcile(ctx context.Context, dryRun bool) (map[string]wg.PeerDiff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var relays []models.Peer
+	if err := s.db.WithContext(ctx).Where(&models.Peer{IsServer: true}).Find(&relays).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]wg.PeerDiff)
+	for _, relay := range relays {
+		if !s.isLocalRelay(relay) {
+			continue
+		}
+		diff, err := s.reconcileRelay(ctx, relay, dryRun)
+		if err != nil {
+			s.logger.Error(ctx, "reconcile read device failed", zap.String("interface", relay.InterfaceName), zap.Error(err))
+			continue
+		}
+		result[relay.InterfaceName] = diff
+	}
+	return result, nil
+}
+
+// RunReconciler 启动时以及之后每隔 interval 执行一次 Reconcile，直到 ctx 结束
+// interval 不大于 0 时只在启动时执行一次
+func (s *Server) RunReconciler(ctx context.Context, interval time.Duration, dryRun bool) {
+	if _, err := s.Reconcile(ctx, dryRun); err != nil {
+		s.logger.Error(ctx, "reconcile failed", zap.Error(err))
+	}
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Reconcile(ctx, dryRun); err != nil {
+				s.logger.Error(ctx, "reconcile failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// reconcileRelay 修正单个中继节点 wg 设备上的节点以及路由的差异，调用方需要持有锁
+func (s *Server) reconcileRelay(ctx context.Context, relay models.Peer, dryRun bool) (wg.PeerDiff, error) {
+	diff, names, err := s.diffRelay(ctx, relay)
+	if err != nil {
+		return diff, err
+	}
+	if !diff.Empty() {
+		s.applyDiff(ctx, relay.InterfaceName, diff, names, dryRun)
+	}
+	if _, err = s.syncRoutes(ctx, s.db, relay, dryRun); err != nil {
+		s.logger.Error(ctx, "reconcile routes failed", zap.String("interface", relay.InterfaceName), zap.Error(err))
+	}
+	return diff, nil
+}
+
+// diffRelay 计算中继节点 wg 设备上的差异，同时返回公钥与节点名的对应关系
+// 设备上需要有所有可以使用该中继节点的节点，以及其他的中继节点
+func (s *Server) diffRelay(ctx context.Context, relay models.Peer) (wg.PeerDiff, map[wgtypes.Key]string, error) {
+	db := s.db.WithContext(ctx)
+	names := make(map[wgtypes.Key]string)
+	peers, err := relay.GetAttachedPeers(db)
+	if err != nil {
+		return wg.PeerDiff{}, names, err
+	}
+	var desired []wgtypes.PeerConfig
+	for _, peer := range peers {
+		config, err := peer.ToWgRelayPeerConfig(relay)
+		if err != nil {
+			s.logger.Warn(ctx, "reconcile skip invalid peer", zap.String("peer", peer.PeerName), zap.Error(err))
+			continue
+		}
+		names[config.PeerConfig.PublicKey] = peer.PeerName
+		desired = append(desired, config.PeerConfig)
+	}
+	relayConfigs, err := relay.ToWgInterRelayConfigs(db)
+	if err != nil {
+		return wg.PeerDiff{}, names, err
+	}
+	for _, config := range relayConfigs {
+		desired = append(desired, config.PeerConfig)
+	}
+	if len(relayConfigs) > 0 {
+		relays, err := models.GetRelayPeers(db)
+		if err != nil {
+			return wg.PeerDiff{}, names, err
+		}
+		for _, other := range relays {
+			if key, err := wgtypes.ParseKey(other.PublicKey); err == nil {
+				names[key] = other.PeerName
+			}
+		}
+	}
+	actual, err := s.operator.GetPeers(relay.InterfaceName)
+	if err != nil {
+		return wg.PeerDiff{}, names, err
+	}
+	return wg.DiffPeers(desired, actual), names, nil
+}
+
+// reconcileAfterChange 节点变化之后修正所有本地中继节点上 pubKeys 对应的节点、中继节点之间的配置以及路由
+// 设备上其他的节点由 Reconcile 按照 dry run 的配置修正，手动添加的节点不会被删除
+// 此时节点的修改已经生效，修正失败只记录日志，之后由 Reconcile 继续修正
+func (s *Server) reconcileAfterChange(ctx context.Context, pubKeys ...string) {
+	relays, err := models.GetRelayPeers(s.db.WithContext(ctx))
+	if err != nil {
+		s.logger.Error(ctx, "reconcile after change failed", zap.Error(err))
+		return
+	}
+	touched := make(map[string]bool)
+	for _, pubKey := range pubKeys {
+		touched[pubKey] = true
+	}
+	for _, relay := range relays {
+		touched[relay.PublicKey] = true
+	}
+	for _, relay := range relays {
+		if !s.isLocalRelay(relay) {
+			continue
+		}
+		diff, names, err := s.diffRelay(ctx, relay)
+		if err != nil {
+			s.logger.Error(ctx, "reconcile after change failed", zap.String("interface", relay.InterfaceName), zap.Error(err))
+			continue
+		}
+		diff = diff.Filter(func(config wgtypes.PeerConfig) bool {
+			return touched[config.PublicKey.String()]
+		})
+		s.applyDiff(ctx, relay.InterfaceName, diff, names, false)
+		// 只修改 wg-tool 添加的路由
+		if _, err = s.syncRoutes(ctx, s.db, relay, false); err != nil {
+			s.logger.Error(ctx, "reconcile routes failed", zap.String("interface", relay.InterfaceName), zap.Error(err))
+		}
+	}
+}
+
+// addRelayPeers 将注册的节点以及其他的中继节点添加到中继节点的 wg 设备上并同步路由
+// 不删除设备上多余的节点，由 Reconcile 按照 dry run 的配置删除
+func (s *Server) addRelayPeers(ctx context.Context, relay models.Peer) error {
+	diff, names, err := s.diffRelay(ctx, relay)
+	if err != nil {
+		return err
+	}
+	diff.Remove = nil
+	s.applyDiff(ctx, relay.InterfaceName, diff, names, false)
+	_, err = s.syncRoutes(ctx, s.db, relay, false)
+	return err
+}
+
+// applyDiff 记录并修正 wg 设备上的差异
+func (s *Server) applyDiff(ctx context.Context, interfaceName string, diff wg.PeerDiff, names map[wgtypes.Key]string, dryRun bool) {
+	apply := func(action string, config wgtypes.PeerConfig, fn func() error) {
+		fields := []zap.Field{
+			zap.String("interface", interfaceName),
+			zap.String("action", action),
+			zap.String("peer", names[config.PublicKey]),
+			zap.String("pubkey", config.PublicKey.String()),
+			zap.Bool("dry_run", dryRun),
+		}
+		if dryRun {
+			s.logger.Info(ctx, "reconcile plan", fields...)
+			return
+		}
+		if err := fn(); err != nil {
+			s.logger.Error(ctx, "reconcile correction failed", append(fields, zap.Error(err))...)
+			return
+		}
+		s.logger.Info(ctx, "reconcile correction", fields...)
+	}
+	for _, config := range diff.Add {
+		apply("add", config, func() error {
+			return s.operator.AddPeer(wg.WgPeerConfig{InterfaceName: interfaceName, PeerConfig: config})
+		})
+	}
+	for _, config := range diff.Update {
+		apply("update", config, func() error {
+			return s.operator.UpdatePeer(wg.WgPeerConfig{InterfaceName: interfaceName, PeerConfig: config})
+		})
+	}
+	for _, config := range diff.Remove {
+		apply("remove", config, func() error {
+			return s.operator.RemovePeer(interfaceName, config.PublicKey)
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/samber/lo"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// RegisterPeer 注册一个节点
+// 为节点分配地址、生成密钥，并将节点添加到中继节点的 wg 设备中
+func (s *Server) RegisterPeer(ctx context.Context, req *pb.RegisterPeerReq) (*pb.RegisterPeerRsp, error) {
+	subnets, err := parseRegisterPeerReq(req)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	clientPubKey, err := s.parseClientPublicKey(req.Pubkey)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	publicIp, listenPort, err := parseEndpoint(req.Endpoint)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	mac, err := peerHardwareAddr(req)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var relays []models.Peer
+	var relayInfos []*pb.RelayPeerInfo
+	var relay, peer models.Peer
+	var priKey, pubKey, psk wgtypes.Key
+	var expireTime *time.Time
+	var added bool // 节点是否已经被添加到 wg 设备中
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if relays, err = getRegisterRelays(tx, req.RelayNames); err != nil {
+			return err
+		}
+		relay = relays[0]
+		var count int64
+		if err = tx.Model(&models.Peer{}).Where("peer_name = ?", req.PeerName).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("%w: %s", errs.WgPeerExistsError, req.PeerName)
+		}
+		if clientPubKey != nil {
+			// 使用节点自己的公钥，服务端不保存私钥
+			pubKey = *clientPubKey
+			if err = tx.Model(&models.Peer{}).Where("public_key = ?", pubKey.String()).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return fmt.Errorf("%w: 公钥已经被使用", errs.WgPeerExistsError)
+			}
+		} else if priKey, pubKey, err = wg.GenerateWgKeyPairs(); err != nil {
+			return err
+		}
+		// 子网不能与覆盖网络、禁止使用的网络以及其他节点的子网重叠
+		if err = models.CheckSubnetConflicts(tx, req.PeerName, subnets.GetNetworks(), s.denied); err != nil {
+			return err
+		}
+		address, err := s.allocateAddress(tx, req.PeerName, pubKey.String(), mac, false)
+		if err != nil {
+			return err
+		}
+		address6, err := s.allocateAddress(tx, req.PeerName, pubKey.String(), mac, true)
+		if err != nil {
+			return err
+		}
+		peer = models.Peer{
+			InterfaceName:     relay.InterfaceName,
+			PublicIp:          publicIp,
+			PeerName:          req.PeerName,
+			PeerAddress:       address,
+			PeerAddress6:      address6,
+			PeerSubnetAddress: subnets,
+			PeerType:          uint(req.PeerType),
+			ConnectTo:         relay.ID,
+			ListenPort:        listenPort,
+			PrivateKey:        privateKeyString(priKey),
+			PublicKey:         pubKey.String(),
+			KeepAliveInterval: relay.KeepAliveInterval,
+			Mesh:              req.Mesh,
+			Static:            req.Static,
+		}
+		if s.pskRequired || req.PresharedKey {
+			if psk, err = wgtypes.GenerateKey(); err != nil {
+				return err
+			}
+			peer.PresharedKey = psk.String()
+		}
+		if relayInfos, err = toRelayPeerInfos(relays, peer); err != nil {
+			return err
+		}
+		if err = tx.Create(&peer).Error; err != nil {
+			return err
+		}
+		if err = models.SetPeerRelays(tx, peer.ID, relays); err != nil {
+			return err
+		}
+		if req.AllocateSubnet {
+			// 节点创建之后再分配，保证分配的子网不会与节点自己声明的子网重叠
+			if peer.PeerSubnetAddress, err = s.allocateSubnet(tx, peer, int(req.SubnetPrefix)); err != nil {
+				return err
+			}
+			if err = tx.Model(&peer).Update("subnet_addresses", peer.PeerSubnetAddress).Error; err != nil {
+				return err
+			}
+		}
+		if expireTime, err = s.renewLease(tx, peer); err != nil {
+			return err
+		}
+		if !s.isLocalRelay(relay) {
+			return nil
+		}
+		config, err := peer.ToWgRelayPeerConfig(relay)
+		if err != nil {
+			return err
+		}
+		// 最后修改 wg 设备，失败时数据库中的修改会被回滚
+		if err = s.operator.AddPeer(config); err != nil {
+			return err
+		}
+		added = true
+		return nil
+	})
+	if err != nil && added {
+		// 数据库提交失败，将节点从 wg 设备中删除
+		if removeErr := s.operator.RemovePeer(relay.InterfaceName, pubKey); removeErr != nil {
+			s.logger.Error(ctx, "remove peer failed", zap.String("peer", req.PeerName), zap.Error(removeErr))
+		}
+	}
+	if err != nil {
+		s.logger.Error(ctx, "register peer failed", zap.String("peer", req.PeerName), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	s.logger.Info(ctx, "register peer", zap.String("peer", peer.PeerName), zap.String("address", peer.PeerAddress.String()),
+		zap.String("address6", peer.PeerAddress6.String()), zap.String("pubkey", peer.PublicKey))
+	// 将节点添加到备用的中继节点，并同步路由
+	s.reconcileAfterChange(ctx, peer.PublicKey)
+
+	return &pb.RegisterPeerRsp{
+		Pubkey:        pubKey.String(),
+		Prikey:        privateKeyString(priKey),
+		Address:       &pb.CidrAddress{Address: peer.PeerAddress.String()},
+		Address6:      toPbAddress(peer.PeerAddress6),
+		RelayPeerInfo: relayInfos,
+		ExpireTime:    unixTime(expireTime),
+		SubNets:       toPbSubnets(peer.PeerSubnetAddress),
+	}, nil
+}
+
+// getRegisterRelays 获取节点注册时指定的中继节点，没有指定时使用所有的中继节点
+func getRegisterRelays(tx *gorm.DB, names []string) ([]models.Peer, error) {
+	if len(names) == 0 {
+		return models.GetRelayPeers(tx)
+	}
+	return models.GetRelayPeersByName(tx, names)
+}
+
+// toRelayPeerInfos 返回给节点的中继节点信息
+func toRelayPeerInfos(relays []models.Peer, peer models.Peer) ([]*pb.RelayPeerInfo, error) {
+	infos := make([]*pb.RelayPeerInfo, 0, len(relays))
+	for _, relay := range relays {
+		endpoint, err := relay.GetEndpoint()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, &pb.RelayPeerInfo{
+			Endpoint:     endpoint.String(),
+			Pubkey:       relay.PublicKey,
+			PresharedKey: peer.PresharedKey,
+			PeerName:     relay.PeerName,
+		})
+	}
+	return infos, nil
+}
+
+// parseRegisterPeerReq 校验注册请求，并解析其中的子网地址
+func parseRegisterPeerReq(req *pb.RegisterPeerReq) (inet.SubnetAddresses, error) {
+	var subnets inet.SubnetAddresses
+	var err error
+	if strings.TrimSpace(req.PeerName) == "" {
+		return subnets, errs.WgInvalidPeerNameError
+	}
+	switch req.PeerType {
+	case pb.PeerType_P2P:
+		if req.AllocateSubnet {
+			return subnets, fmt.Errorf("%w: 只有 SubNet 节点可以分配子网", errs.WgInvalidPeerTypeError)
+		}
+		return subnets, nil
+	case pb.PeerType_SubNet:
+	default:
+		return subnets, fmt.Errorf("%w: %s", errs.WgInvalidPeerTypeError, req.PeerType)
+	}
+	if subnets, err = parseSubnets(req.SubNets); err != nil {
+		return subnets, err
+	}
+	if len(subnets.GetAddresses()) == 0 && !req.AllocateSubnet {
+		return subnets, fmt.Errorf("%w: SubNet 节点需要指定子网地址或者由服务端分配子网", errs.WgInvalidAddressError)
+	}
+	return subnets, nil
+}
+
+// parseSubnets 解析请求中的子网地址，并规范化为最少的子网以减少 AllowedIPs 以及路由的数量
+func parseSubnets(cidrs []*pb.CidrAddress) (inet.SubnetAddresses, error) {
+	var subnets inet.SubnetAddresses
+	var err error
+	addrs := lo.Map(cidrs, func(item *pb.CidrAddress, _ int) string {
+		return item.GetAddress()
+	})
+	if len(addrs) == 0 {
+		return subnets, nil
+	}
+	if subnets, err = inet.NewSubnetAddressesFromString(strings.Join(addrs, ",")); err != nil {
+		return subnets, fmt.Errorf("%w: %w", errs.WgInvalidAddressError, err)
+	}
+	return subnets.Normalize(), nil
+}
+
+// parseEndpoint 解析节点的公网端点，为空时表示节点没有公网地址
+func parseEndpoint(endpoint string) (publicIp string, port uint16, err error) {
+	if endpoint == "" {
+		return
+	}
+	addrPort, err := netip.ParseAddrPort(endpoint)
+	if err != nil || addrPort.Port() == 0 {
+		err = fmt.Errorf("%w: endpoint %s", errs.WgInvalidAddressError, endpoint)
+		return
+	}
+	return addrPort.Addr().Unmap().String(), addrPort.Port(), nil
+}
+
+// peerHardwareAddr 节点在地址池中的 MAC 地址，没有指定时根据节点名生成
+func peerHardwareAddr(req *pb.RegisterPeerReq) (net.HardwareAddr, error) {
+	if req.HardwareAddr == "" {
+		return models.PeerHardwareAddr(req.PeerName), nil
+	}
+	mac, err := net.ParseMAC(req.HardwareAddr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errs.WgInvalidAddressError, err)
+	}
+	return mac, nil
+}
+
+// parseClientPublicKey 解析节点自己携带的公钥
+// 节点没有携带公钥时返回 nil，表示由服务端生成密钥对
+func (s *Server) parseClientPublicKey(pubkey string) (*wgtypes.Key, error) {
+	if pubkey == "" {
+		if !s.keygen {
+			return nil, errs.WgKeygenDisabledError
+		}
+		return nil, nil
+	}
+	key, err := wgtypes.ParseKey(pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errs.WgInvalidKeyError, err)
+	}
+	return &key, nil
+}
+
+// privateKeyString 私钥转换为字符串，没有私钥时为空
+func privateKeyString(key wgtypes.Key) string {
+	if key == (wgtypes.Key{}) {
+		return ""
+	}
+	return key.String()
+}
+
+// addressPool 获取地址池，所有的中继节点共用第一个中继节点的地址池
+// ipv6 为 true 时获取 IPv6 的地址池，网络没有开启双栈时地址池为 nil
+func addressPool(tx *gorm.DB, ipv6 bool) (*models.DhcpStorage, []models.Peer, error) {
+	relays, err := models.GetRelayPeers(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cidr := poolAddress(relays[0], ipv6)
+	if cidr.IsZero() {
+		return nil, relays, nil
+	}
+	return models.NewDHCPStorage(tx, cidr), relays, nil
+}
+
+// poolAddress 中继节点在地址池中的地址
+func poolAddress(relay models.Peer, ipv6 bool) inet.CidrAddress {
+	if ipv6 {
+		return relay.PeerAddress6
+	}
+	return relay.PeerAddress
+}
+
+// allocateAddress 从中继节点所在的网络中为节点分配地址
+// 节点有预留的地址时使用预留的地址，否则使用地址池的分配策略在地址池的范围中分配
+// 网络没有开启双栈时分配 IPv6 地址返回空地址
+func (s *Server) allocateAddress(tx *gorm.DB, peerName, publicKey string, mac net.HardwareAddr, ipv6 bool) (inet.CidrAddress, error) {
+	storage, relays, err := addressPool(tx, ipv6)
+	if err != nil || storage == nil {
+		return inet.CidrAddress{}, err
+	}
+	var ip net.IP
+	// 查找以及占用地址在同一个事务中执行，地址被其他服务端同时占用时重新分配
+	err = storage.WithPool(s.poolConfig(ipv6)).Transaction(func(storage *models.DhcpStorage) error {
+		if err := reserveRelayAddresses(storage, relays, ipv6); err != nil {
+			return err
+		}
+		reservation, err := storage.GetReservation(peerName, publicKey, mac)
+		if err != nil {
+			return err
+		}
+		if reservation != nil {
+			ip = net.IP(reservation.Address)
+			return storage.SetAddressWithMAC(ip, mac)
+		}
+		allocator, err := models.NewAddressAllocator(s.allocateStrategy(ipv6), storage)
+		if err != nil {
+			return err
+		}
+		ip, err = allocator.AllocateAddress(mac, publicKey)
+		return err
+	})
+	if err != nil {
+		return inet.CidrAddress{}, err
+	}
+	return inet.NewCidrAddress(ip, poolAddress(relays[0], ipv6).GetNetwork()), nil
+}
+
+// reserveRelayAddresses 中继节点本身的地址不能分配给其他节点
+func reserveRelayAddresses(storage *models.DhcpStorage, relays []models.Peer, ipv6 bool) error {
+	for _, relay := range relays {
+		relayIp := poolAddress(relay, ipv6).GetAddress()
+		if relayIp == nil {
+			continue
+		}
+		used, err := storage.IsUsed(relayIp)
+		if err != nil {
+			return err
+		}
+		if used {
+			continue
+		}
+		if err = storage.SetAddressWithMAC(relayIp, models.PeerHardwareAddr(relay.PeerName)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseAddress 释放节点的地址
+func releaseAddress(tx *gorm.DB, peer models.Peer) error {
+	for _, ipv6 := range []bool{false, true} {
+		address := poolAddress(peer, ipv6)
+		if address.IsZero() {
+			continue
+		}
+		storage, _, err := addressPool(tx, ipv6)
+		if err != nil {
+			return err
+		}
+		if storage == nil {
+			continue
+		}
+		if err = storage.ReleaseAddress(address.GetAddress()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
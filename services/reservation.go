@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// AddReservation 在地址所在的地址池中为节点预留地址，节点注册时总是使用该地址
+func (s *Server) AddReservation(ctx context.Context, req *pb.Reservation) (*pb.Reservation, error) {
+	reservation, err := parseReservation(req)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		storage, err := reservationPool(tx, net.IP(reservation.Address))
+		if err != nil {
+			return err
+		}
+		reservation, err = storage.AddReservation(reservation)
+		return err
+	})
+	if err != nil {
+		s.logger.Error(ctx, "add reservation failed", zap.String("address", req.Address), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	s.logger.Info(ctx, "add reservation", zap.String("address", reservation.Address.String()),
+		zap.String("peer", reservation.PeerName), zap.String("pubkey", reservation.PublicKey),
+		zap.String("mac", reservation.HardwareAddr.String()))
+	return toPbReservation(reservation), nil
+}
+
+// DeleteReservation 删除预留的地址，已经使用该地址的节点不受影响
+func (s *Server) DeleteReservation(ctx context.Context, req *pb.DeleteReservationReq) (*pb.EmptyRsp, error) {
+	ip := net.ParseIP(req.Address)
+	if ip == nil {
+		return nil, toStatusError(fmt.Errorf("%w: %s", errs.WgInvalidAddressError, req.Address))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	storage, err := reservationPool(s.db, ip)
+	if err == nil {
+		err = storage.DeleteReservation(ip)
+	}
+	if err != nil {
+		s.logger.Error(ctx, "delete reservation failed", zap.String("address", req.Address), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	s.logger.Info(ctx, "delete reservation", zap.String("address", ip.String()))
+	return &pb.EmptyRsp{}, nil
+}
+
+// ListReservations 获取 IPv4 以及 IPv6 地址池中所有预留的地址
+func (s *Server) ListReservations(ctx context.Context, req *pb.ListReservationsReq) (*pb.ListReservationsRsp, error) {
+	rsp := &pb.ListReservationsRsp{}
+	for _, ipv6 := range []bool{false, true} {
+		storage, _, err := addressPool(s.db, ipv6)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		if storage == nil {
+			continue
+		}
+		reservations, err := storage.GetReservations()
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		for _, reservation := range reservations {
+			rsp.Reservations = append(rsp.Reservations, toPbReservation(reservation))
+		}
+	}
+	return rsp, nil
+}
+
+// reservationPool 获取地址所在的地址池
+func reservationPool(tx *gorm.DB, ip net.IP) (*models.DhcpStorage, error) {
+	storage, _, err := addressPool(tx, ip.To4() == nil)
+	if err != nil {
+		return nil, err
+	}
+	if storage == nil {
+		return nil, fmt.Errorf("%w: 网络没有开启 IPv6", errs.WgInvalidAddressError)
+	}
+	return storage, nil
+}
+
+// parseReservation 校验并解析预留地址的请求
+func parseReservation(req *pb.Reservation) (models.AddressReservation, error) {
+	reservation := models.AddressReservation{Remark: req.Remark}
+	ip := net.ParseIP(req.Address)
+	if ip == nil {
+		return reservation, fmt.Errorf("%w: %s", errs.WgInvalidAddressError, req.Address)
+	}
+	reservation.Address = inet.IpAddress(ip)
+	switch key := req.Key.(type) {
+	case *pb.Reservation_PeerName:
+		if strings.TrimSpace(key.PeerName) == "" {
+			return reservation, errs.WgInvalidPeerNameError
+		}
+		reservation.PeerName = key.PeerName
+	case *pb.Reservation_Pubkey:
+		pubKey, err := wgtypes.ParseKey(key.Pubkey)
+		if err != nil {
+			return reservation, fmt.Errorf("%w: %w", errs.WgInvalidKeyError, err)
+		}
+		reservation.PublicKey = pubKey.String()
+	case *pb.Reservation_HardwareAddr:
+		mac, err := net.ParseMAC(key.HardwareAddr)
+		if err != nil {
+			return reservation, fmt.Errorf("%w: %w", errs.WgInvalidAddressError, err)
+		}
+		reservation.HardwareAddr = mac
+	default:
+		return reservation, fmt.Errorf("%w: 需要指定节点名、公钥或者 MAC 地址", errs.WgInvalidReservationError)
+	}
+	return reservation, nil
+}
+
+// toPbReservation 转换为返回的预留地址
+func toPbReservation(reservation models.AddressReservation) *pb.Reservation {
+	rsp := &pb.Reservation{
+		Address: reservation.Address.String(),
+		Remark:  reservation.Remark,
+	}
+	switch {
+	case reservation.PeerName != "":
+		rsp.Key = &pb.Reservation_PeerName{PeerName: reservation.PeerName}
+	case reservation.PublicKey != "":
+		rsp.Key = &pb.Reservation_Pubkey{Pubkey: reservation.PublicKey}
+	default:
+		rsp.Key = &pb.Reservation_HardwareAddr{HardwareAddr: reservation.HardwareAddr.String()}
+	}
+	return rsp
+}
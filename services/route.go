@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"net"
+
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// syncRoutes 使中继节点 wg 接口上的路由与所有 SubNet 节点的子网地址保持一致
+// dryRun 为 true 时只输出需要修正的路由，不修改路由表
+func (s *Server) syncRoutes(ctx context.Context, db *gorm.DB, relay models.Peer, dryRun bool) (wg.RouteDiff, error) {
+	var diff wg.RouteDiff
+	config := wg.RouteConfig{
+		InterfaceName: relay.InterfaceName,
+		Table:         s.routeTable,
+		Metric:        s.routeMetric,
+	}
+	desired, err := relay.GetRoutedSubnets(db.WithContext(ctx))
+	if err != nil {
+		return diff, err
+	}
+	actual, err := s.operator.GetRoutes(config)
+	if err != nil {
+		return diff, err
+	}
+	diff = wg.DiffRoutes(desired, actual)
+	if diff.Empty() {
+		return diff, nil
+	}
+	apply := func(action string, networks []net.IPNet, fn func(wg.RouteConfig, []net.IPNet) error) error {
+		if len(networks) == 0 {
+			return nil
+		}
+		fields := []zap.Field{
+			zap.String("interface", relay.InterfaceName),
+			zap.String("action", action),
+			zap.Stringers("routes", networkStringers(networks)),
+			zap.Bool("dry_run", dryRun),
+		}
+		if dryRun {
+			s.logger.Info(ctx, "route plan", fields...)
+			return nil
+		}
+		if err := fn(config, networks); err != nil {
+			return err
+		}
+		s.logger.Info(ctx, "route correction", fields...)
+		return nil
+	}
+	if err = apply("add", diff.Add, s.operator.AddRoutes); err != nil {
+		return diff, err
+	}
+	return diff, apply("remove", diff.Remove, s.operator.DelRoutes)
+}
+
+// networkStringers 将网络地址转换为日志中输出的格式
+func networkStringers(networks []net.IPNet) []*net.IPNet {
+	result := make([]*net.IPNet, 0, len(networks))
+	for i := range networks {
+		result = append(result, &networks[i])
+	}
+	return result
+}
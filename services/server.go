@@ -0,0 +1,191 @@
+// Package services 实现 WireguardTool 定义的 gRPC 接口
+package services
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/onesaltedseafish/go-utils/log"
+	"github.com/onesaltedseafish/go-utils/simulate/dhcp"
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+var _ pb.WireguardToolServer = (*Server)(nil)
+
+// Server WireguardTool 服务端实现
+type Server struct {
+	pb.UnimplementedWireguardToolServer
+	db          *gorm.DB
+	logger      *log.Logger
+	operator    WgOperator
+	keygen      bool                    // 是否允许服务端为节点生成密钥对
+	pskRequired bool                    // 是否必须使用预共享密钥
+	routeTable  int                     // 子网路由所在的路由表，为 0 时使用 main 表
+	routeMetric int                     // 子网路由的优先级
+	topology    models.Topology         // 网络拓扑
+	strategy    models.AllocateStrategy // IPv4 地址池的分配策略
+	strategy6   models.AllocateStrategy // IPv6 地址池的分配策略
+	lease       time.Duration           // IPv4 地址的租约时长，不大于 0 时不会过期
+	lease6      time.Duration           // IPv6 地址的租约时长，不大于 0 时不会过期
+	pool        models.PoolConfig       // IPv4 地址池可以分配的地址范围
+	pool6       models.PoolConfig       // IPv6 地址池可以分配的地址范围
+	supernet    net.IPNet               // 为 SubNet 节点分配子网的超网，为空时不分配
+	subnetSize  int                     // 默认分配的子网的前缀长度
+	denied      []net.IPNet             // 节点的子网不能使用的网络，例如中继节点所在的局域网
+	localRelays map[uint]bool           // 本地启动的中继节点，为空时所有的中继节点都在本地
+	mu          sync.Mutex              // 串行化对地址池以及 wg 设备的修改
+}
+
+// NewServer 初始化服务端
+func NewServer(db *gorm.DB, logger *log.Logger) *Server {
+	return &Server{
+		db:          db,
+		logger:      logger,
+		operator:    KernelOperator{},
+		keygen:      true,
+		topology:    models.TopologyHub,
+		strategy:    models.StrategySequential,
+		strategy6:   models.StrategySequential,
+		localRelays: make(map[uint]bool),
+	}
+}
+
+// WithWgOperator 替换对 wg 设备的操作
+func (s *Server) WithWgOperator(operator WgOperator) *Server {
+	s.operator = operator
+	return s
+}
+
+// WithServerKeygen 设置是否允许服务端为节点生成密钥对
+// 不允许时节点注册必须携带自己的公钥
+func (s *Server) WithServerKeygen(enable bool) *Server {
+	s.keygen = enable
+	return s
+}
+
+// WithPresharedKeyRequired 设置是否必须使用预共享密钥
+// 必须使用时每个节点注册时都会生成预共享密钥
+func (s *Server) WithPresharedKeyRequired(required bool) *Server {
+	s.pskRequired = required
+	return s
+}
+
+// WithRouteConfig 设置子网路由所在的路由表以及路由的优先级
+func (s *Server) WithRouteConfig(table, metric int) *Server {
+	s.routeTable = table
+	s.routeMetric = metric
+	return s
+}
+
+// WithTopology 设置网络拓扑
+func (s *Server) WithTopology(topology models.Topology) *Server {
+	s.topology = topology
+	return s
+}
+
+// WithAllocateStrategy 设置 IPv4 以及 IPv6 地址池的分配策略
+func (s *Server) WithAllocateStrategy(strategy, strategy6 models.AllocateStrategy) *Server {
+	s.strategy = strategy
+	s.strategy6 = strategy6
+	return s
+}
+
+// WithLease 设置 IPv4 以及 IPv6 地址的租约时长，节点需要在到期之前续租
+func (s *Server) WithLease(lease, lease6 time.Duration) *Server {
+	s.lease = lease
+	s.lease6 = lease6
+	return s
+}
+
+// WithAddressPool 设置 IPv4 以及 IPv6 地址池可以分配的地址范围以及排除的地址
+func (s *Server) WithAddressPool(pool, pool6 models.PoolConfig) *Server {
+	s.pool = pool
+	s.pool6 = pool6
+	return s
+}
+
+// WithSubnetPool 设置为 SubNet 节点分配子网的超网以及默认分配的前缀长度
+func (s *Server) WithSubnetPool(supernet net.IPNet, prefix int) *Server {
+	s.supernet = supernet
+	s.subnetSize = prefix
+	return s
+}
+
+// WithDeniedSubnets 设置节点的子网不能使用的网络，节点注册以及修改子网时校验，分配子网时跳过
+func (s *Server) WithDeniedSubnets(denied []net.IPNet) *Server {
+	s.denied = denied
+	return s
+}
+
+// allocateStrategy 获取地址池的分配策略
+func (s *Server) allocateStrategy(ipv6 bool) models.AllocateStrategy {
+	if ipv6 {
+		return s.strategy6
+	}
+	return s.strategy
+}
+
+// poolConfig 获取地址池可以分配的地址范围
+func (s *Server) poolConfig(ipv6 bool) models.PoolConfig {
+	if ipv6 {
+		return s.pool6
+	}
+	return s.pool
+}
+
+// isLocalRelay 中继节点是否在本地，只有本地的中继节点可以直接修改 wg 设备
+// 其他中继节点由它们自己的服务端通过 Reconcile 同步
+func (s *Server) isLocalRelay(relay models.Peer) bool {
+	return len(s.localRelays) == 0 || s.localRelays[relay.ID]
+}
+
+// toStatusError 将内部错误转换为 gRPC 的错误码
+func toStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, errs.WgInvalidPeerNameError),
+		errors.Is(err, errs.WgInvalidPeerTypeError),
+		errors.Is(err, errs.WgInvalidAddressError),
+		errors.Is(err, errs.WgRelayPeerError),
+		errors.Is(err, errs.InvalidPageTokenError),
+		errors.Is(err, errs.WgInvalidKeyError),
+		errors.Is(err, errs.WgInvalidKeepAliveError),
+		errors.Is(err, errs.WgKeygenDisabledError),
+		errors.Is(err, errs.WgInvalidTopologyError),
+		errors.Is(err, errs.WgInvalidRelayError),
+		errors.Is(err, errs.WgInvalidReservationError),
+		errors.Is(err, errs.WgInvalidPoolError),
+		errors.Is(err, errs.WgInvalidPrefixError),
+		errors.Is(err, errs.WgSubnetConflictError):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, errs.WgPeerNotFoundError),
+		errors.Is(err, errs.WgLeaseNotFoundError),
+		errors.Is(err, errs.WgReservationNotFoundError):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errs.WgPeerExistsError),
+		errors.Is(err, errs.WgReservationExistsError):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, errs.WgAddressConflictError):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, errs.WgNoRelayPeerError),
+		errors.Is(err, errs.WgNoSupernetError):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, errs.WgPoolExhaustedError),
+		errors.Is(err, errs.WgSubnetExhaustedError),
+		errors.Is(err, dhcp.ErrHasNotEnoughAddr):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
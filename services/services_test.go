@@ -0,0 +1,1350 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/onesaltedseafish/go-utils/log"
+	gormlog "github.com/onesaltedseafish/go-utils/log/gorm"
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"github.com/onesaltedseafish/wg-tool/services"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+const (
+	testSqlitePath = "test.sqlite"
+)
+
+var (
+	testDb     *gorm.DB
+	err        error
+	logOpt     = log.CommonLogOpt.WithDirectory("logs").WithLogLevel(zapcore.DebugLevel).WithTraceIDEnable(false).WithConsoleLog(false)
+	logger     = log.GetLogger("services-test", &logOpt)
+	gormLogger = gormlog.NewLogger("gorm", &logOpt)
+	ctx        = context.Background()
+)
+
+func init() {
+	testDb, err = models.InitDb(models.InitSqlite(testSqlitePath), true, gormLogger)
+	if err != nil {
+		logger.Fatal(ctx, "init db error", zap.Error(err))
+	}
+}
+
+// fakeOperator 记录对 wg 设备的操作，不真正修改内核
+type fakeOperator struct {
+	server  *wg.WgServerConfig                    // 启动的 wg 接口
+	peers   map[string]wg.WgPeerConfig            // wg0 上的节点 map[pubkey] config
+	devices map[string]map[string]wg.WgPeerConfig // 所有 wg 接口上的节点 map[interface]map[pubkey] config
+	routes  map[string]net.IPNet                  // map[network] 经过 wg 接口的路由
+	err     error                                 // 不为空时所有操作都返回该错误
+}
+
+func newFakeOperator() *fakeOperator {
+	peers := make(map[string]wg.WgPeerConfig)
+	return &fakeOperator{
+		peers:   peers,
+		devices: map[string]map[string]wg.WgPeerConfig{"wg0": peers},
+		routes:  make(map[string]net.IPNet),
+	}
+}
+
+// device 获取 wg 接口上的节点
+func (o *fakeOperator) device(interfaceName string) map[string]wg.WgPeerConfig {
+	if _, ok := o.devices[interfaceName]; !ok {
+		o.devices[interfaceName] = make(map[string]wg.WgPeerConfig)
+	}
+	return o.devices[interfaceName]
+}
+
+func (o *fakeOperator) SetupInterface(config wg.WgServerConfig) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.server = &config
+	return nil
+}
+
+func (o *fakeOperator) AddPeer(config wg.WgPeerConfig) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.device(config.InterfaceName)[config.PeerConfig.PublicKey.String()] = config
+	return nil
+}
+
+func (o *fakeOperator) UpdatePeer(config wg.WgPeerConfig) error {
+	if o.err != nil {
+		return o.err
+	}
+	o.device(config.InterfaceName)[config.PeerConfig.PublicKey.String()] = config
+	return nil
+}
+
+func (o *fakeOperator) RemovePeer(interfaceName string, publicKey wgtypes.Key) error {
+	if o.err != nil {
+		return o.err
+	}
+	delete(o.device(interfaceName), publicKey.String())
+	return nil
+}
+
+func (o *fakeOperator) GetPeers(interfaceName string) ([]wgtypes.Peer, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	var peers []wgtypes.Peer
+	for _, config := range o.device(interfaceName) {
+		peer := wgtypes.Peer{
+			PublicKey:  config.PeerConfig.PublicKey,
+			Endpoint:   config.PeerConfig.Endpoint,
+			AllowedIPs: config.PeerConfig.AllowedIPs,
+		}
+		if config.PeerConfig.PersistentKeepaliveInterval != nil {
+			peer.PersistentKeepaliveInterval = *config.PeerConfig.PersistentKeepaliveInterval
+		}
+		if config.PeerConfig.PresharedKey != nil {
+			peer.PresharedKey = *config.PeerConfig.PresharedKey
+		}
+		peers = append(peers, peer)
+	}
+	return peers, nil
+}
+
+func (o *fakeOperator) AddRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	if o.err != nil {
+		return o.err
+	}
+	for _, network := range networks {
+		o.routes[network.String()] = network
+	}
+	return nil
+}
+
+func (o *fakeOperator) DelRoutes(config wg.RouteConfig, networks []net.IPNet) error {
+	if o.err != nil {
+		return o.err
+	}
+	for _, network := range networks {
+		delete(o.routes, network.String())
+	}
+	return nil
+}
+
+func (o *fakeOperator) GetRoutes(config wg.RouteConfig) ([]net.IPNet, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	return lo.Values(o.routes), nil
+}
+
+// routesString 返回排序后的路由，方便比较
+func (o *fakeOperator) routesString() []string {
+	routes := lo.Keys(o.routes)
+	slices.Sort(routes)
+	return routes
+}
+
+// networksString 将网络地址转换为字符串，方便比较
+func networksString(networks []net.IPNet) []string {
+	return lo.Map(networks, func(item net.IPNet, _ int) string {
+		return item.String()
+	})
+}
+
+// resetRelayAddress resetDb 创建的中继节点的地址
+var resetRelayAddress, _ = inet.NewCidrAddressFromString("192.168.222.1/24")
+
+// clearDb 清空所有的表
+func clearDb(t *testing.T) {
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.Peer{}).Error)
+	assert.Equal(t, nil, testDb.Unscoped().Where("1 = 1").Delete(&models.DhcpClient{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.PeerRelay{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressReservation{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.SubnetAllocation{}).Error)
+	assert.Equal(t, nil, testDb.Where("1 = 1").Delete(&models.AddressCursor{}).Error)
+}
+
+// resetDb 清空所有的表，并创建一个中继节点
+func resetDb(t *testing.T) models.Peer {
+	clearDb(t)
+	relayPrivKey, relayPubKey, _ := wg.GenerateWgKeyPairs()
+	relay := models.Peer{
+		InterfaceName:     "wg0",
+		PeerName:          "relay",
+		PeerAddress:       resetRelayAddress,
+		PeerType:          uint(pb.PeerType_P2P),
+		IsServer:          true,
+		ListenPort:        51820,
+		PrivateKey:        relayPrivKey.String(),
+		PublicKey:         relayPubKey.String(),
+		PublicIp:          "1.2.3.4",
+		KeepAliveInterval: 25,
+	}
+	assert.Equal(t, nil, testDb.Create(&relay).Error)
+	return relay
+}
+
+func TestRegisterPeer(t *testing.T) {
+	relay := resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node1",
+		PeerType: pb.PeerType_P2P,
+	})
+	assert.Equal(t, nil, err)
+	// 中继节点自己的地址不会被分配出去
+	assert.Equal(t, "192.168.222.2/24", rsp.Address.Address)
+	assert.Equal(t, "1.2.3.4:51820", rsp.RelayPeerInfo[0].Endpoint)
+	assert.Equal(t, relay.PublicKey, rsp.RelayPeerInfo[0].Pubkey)
+	assert.NotEqual(t, "", rsp.Prikey)
+
+	// 数据库中的记录
+	var peer models.Peer
+	assert.Equal(t, nil, testDb.Where("peer_name = ?", "node1").First(&peer).Error)
+	assert.Equal(t, relay.ID, peer.ConnectTo)
+	assert.Equal(t, rsp.Pubkey, peer.PublicKey)
+	assert.Equal(t, relay.KeepAliveInterval, peer.KeepAliveInterval)
+
+	// 中继节点的 wg 设备
+	config, ok := operator.peers[rsp.Pubkey]
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "wg0", config.InterfaceName)
+	assert.Equal(t, []string{"192.168.222.2/32"}, networksString(config.PeerConfig.AllowedIPs))
+
+	// 重复注册
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node1",
+		PeerType: pb.PeerType_P2P,
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// SubNet 节点必须携带子网地址
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node2",
+		PeerType: pb.PeerType_SubNet,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRegisterPeerRollback(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	operator.err = errors.New("configure device failed")
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+
+	_, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node1",
+		PeerType: pb.PeerType_SubNet,
+		SubNets:  []*pb.CidrAddress{{Address: "10.10.0.0/24"}},
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	// 修改 wg 设备失败时，数据库中不能留下任何记录
+	var count int64
+	assert.Equal(t, nil, testDb.Model(&models.Peer{}).Where("peer_name = ?", "node1").Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, nil, testDb.Model(&models.DhcpClient{}).Where("enable = ?", true).Count(&count).Error)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUnregisterPeer(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node1",
+		PeerType: pb.PeerType_P2P,
+	})
+	assert.Equal(t, nil, err)
+
+	// 删除 wg 设备失败，数据库保持不变
+	operator.err = errors.New("configure device failed")
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node1"})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	_, err = models.GetPeerByName(testDb, "node1")
+	assert.Equal(t, nil, err)
+	used, err := models.NewDHCPStorage(testDb, resetRelayAddress).IsUsed(net.ParseIP("192.168.222.2"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, used)
+
+	// 正常注销
+	operator.err = nil
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node1"})
+	assert.Equal(t, nil, err)
+	_, ok := operator.peers[rsp.Pubkey]
+	assert.Equal(t, false, ok)
+	_, err = models.GetPeerByName(testDb, "node1")
+	assert.ErrorIs(t, err, errs.WgPeerNotFoundError)
+	used, err = models.NewDHCPStorage(testDb, resetRelayAddress).IsUsed(net.ParseIP("192.168.222.2"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, used)
+	// 记录只是被软删除
+	var count int64
+	assert.Equal(t, nil, testDb.Unscoped().Model(&models.Peer{}).Where("peer_name = ?", "node1").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// 不存在的节点以及中继节点都不能被注销
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "relay"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestListPeers(t *testing.T) {
+	resetDb(t)
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+	for _, req := range []*pb.RegisterPeerReq{
+		{PeerName: "office_1", PeerType: pb.PeerType_SubNet, SubNets: []*pb.CidrAddress{{Address: "10.1.0.0/24"}}},
+		{PeerName: "office_2", PeerType: pb.PeerType_SubNet, SubNets: []*pb.CidrAddress{{Address: "10.2.0.0/24"}}},
+		{PeerName: "officeX3", PeerType: pb.PeerType_P2P},
+		{PeerName: "laptop", PeerType: pb.PeerType_P2P},
+	} {
+		_, err := server.RegisterPeer(ctx, req)
+		assert.Equal(t, nil, err)
+	}
+
+	// 分页遍历所有节点
+	var names []string
+	var pageToken string
+	for {
+		rsp, err := server.ListPeers(ctx, &pb.ListPeersReq{PageSize: 2, PageToken: pageToken})
+		assert.Equal(t, nil, err)
+		assert.LessOrEqual(t, len(rsp.Peers), 2)
+		for _, peer := range rsp.Peers {
+			names = append(names, peer.PeerName)
+		}
+		if rsp.NextPageToken == "" {
+			break
+		}
+		pageToken = rsp.NextPageToken
+	}
+	assert.Equal(t, []string{"relay", "office_1", "office_2", "officeX3", "laptop"}, names)
+
+	// 过滤条件
+	isServer := false
+	testcases := []struct {
+		Req  *pb.ListPeersReq
+		Want int
+	}{
+		{&pb.ListPeersReq{PeerType: pb.PeerType_SubNet}, 2},
+		{&pb.ListPeersReq{IsServer: &isServer}, 4},
+		{&pb.ListPeersReq{NamePrefix: "office_"}, 2}, // _ 不作为通配符
+		{&pb.ListPeersReq{NamePrefix: "office"}, 3},
+		{&pb.ListPeersReq{InterfaceName: "wg1"}, 0},
+	}
+	for _, testcase := range testcases {
+		rsp, err := server.ListPeers(ctx, testcase.Req)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, testcase.Want, len(rsp.Peers))
+	}
+
+	_, err := server.ListPeers(ctx, &pb.ListPeersReq{PageToken: "invalid token"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGetPeer(t *testing.T) {
+	resetDb(t)
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "office",
+		PeerType: pb.PeerType_SubNet,
+		SubNets:  []*pb.CidrAddress{{Address: "10.1.0.0/24"}, {Address: "10.2.0.0/24"}},
+	})
+	assert.Equal(t, nil, err)
+
+	byName, err := server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "office"}})
+	assert.Equal(t, nil, err)
+	byKey, err := server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_Pubkey{Pubkey: rsp.Pubkey}})
+	assert.Equal(t, nil, err)
+	for _, info := range []*pb.PeerInfo{byName, byKey} {
+		assert.Equal(t, "office", info.PeerName)
+		assert.Equal(t, rsp.Pubkey, info.Pubkey)
+		assert.Equal(t, rsp.Address.Address, info.Address.Address)
+		assert.Equal(t, 2, len(info.SubNets))
+		assert.Equal(t, "10.1.0.0/24", info.SubNets[0].Address)
+		assert.Equal(t, int32(25), info.KeepAliveInterval)
+		assert.Equal(t, "", info.Endpoint)
+	}
+
+	relay, err := server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "relay"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "1.2.3.4:51820", relay.Endpoint)
+
+	_, err = server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "not-exist"}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	_, err = server.GetPeer(ctx, &pb.GetPeerReq{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestRegisterPeerWithPublicKey(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator).WithServerKeygen(false)
+
+	// 不允许服务端生成密钥
+	_, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	// 非法的公钥
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P, Pubkey: "invalid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, pubKey, _ := wg.GenerateWgKeyPairs()
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node1",
+		PeerType: pb.PeerType_P2P,
+		Pubkey:   pubKey.String(),
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, pubKey.String(), rsp.Pubkey)
+	assert.Equal(t, "", rsp.Prikey)
+	_, ok := operator.peers[pubKey.String()]
+	assert.Equal(t, true, ok)
+	peer, err := models.GetPeerByName(testDb, "node1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, pubKey.String(), peer.PublicKey)
+	assert.Equal(t, "", peer.PrivateKey)
+
+	// 同一个公钥不能注册两次
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "node2",
+		PeerType: pb.PeerType_P2P,
+		Pubkey:   pubKey.String(),
+	})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestUpdatePeer(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+
+	// 修改为 SubNet 节点但是没有子网地址
+	subnet := pb.PeerType_SubNet
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node1", PeerType: &subnet})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// 保活时长超出 wg 设备支持的范围
+	for _, keepalive := range []int32{-1, 65536} {
+		_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node1", KeepAliveInterval: &keepalive})
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+	keepalive := int32(65535)
+	info, err := server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node1", KeepAliveInterval: &keepalive})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int32(65535), info.KeepAliveInterval)
+
+	keepalive = int32(0)
+	remark := "office"
+	info, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName:          "node1",
+		PeerType:          &subnet,
+		SubNets:           &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "10.1.0.0/24"}}},
+		KeepAliveInterval: &keepalive,
+		Remark:            &remark,
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, pb.PeerType_SubNet, info.PeerType)
+	assert.Equal(t, "10.1.0.0/24", info.SubNets[0].Address)
+	assert.Equal(t, int32(0), info.KeepAliveInterval)
+	assert.Equal(t, "office", info.Remark)
+	// 地址和密钥保持不变
+	assert.Equal(t, rsp.Address.Address, info.Address.Address)
+	assert.Equal(t, rsp.Pubkey, info.Pubkey)
+	// wg 设备上的 AllowedIPs 被替换
+	config := operator.peers[rsp.Pubkey]
+	assert.Equal(t, []string{"192.168.222.2/32", "10.1.0.0/24"}, networksString(config.PeerConfig.AllowedIPs))
+	assert.Equal(t, true, config.PeerConfig.ReplaceAllowedIPs)
+	assert.Nil(t, config.PeerConfig.PersistentKeepaliveInterval)
+
+	// 修改 wg 设备失败时数据库保持不变
+	operator.err = errors.New("configure device failed")
+	remark = "changed"
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node1", Remark: &remark})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	peer, err := models.GetPeerByName(testDb, "node1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "office", peer.Remark)
+
+	// 中继节点不能被修改
+	operator.err = nil
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "relay", Remark: &remark})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestReconcile(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+	rsp1, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	rsp2, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node2", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	diffs, err := server.Reconcile(ctx, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, diffs["wg0"].Empty())
+
+	// 设备与数据库产生偏差：缺少 node1，node2 的 AllowedIPs 被修改，多出一个未知的节点
+	delete(operator.peers, rsp1.Pubkey)
+	node2 := operator.peers[rsp2.Pubkey]
+	node2.PeerConfig.AllowedIPs = nil
+	operator.peers[rsp2.Pubkey] = node2
+	_, unknownKey, _ := wg.GenerateWgKeyPairs()
+	operator.peers[unknownKey.String()] = wg.WgPeerConfig{
+		InterfaceName: "wg0",
+		PeerConfig:    wgtypes.PeerConfig{PublicKey: unknownKey},
+	}
+
+	// 节点变化之后只修改变化的节点，其他的偏差由 Reconcile 修正
+	rsp3, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node3", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(operator.peers))
+	_, ok := operator.peers[unknownKey.String()]
+	assert.Equal(t, true, ok)
+	_, ok = operator.peers[rsp1.Pubkey]
+	assert.Equal(t, false, ok)
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node3"})
+	assert.Equal(t, nil, err)
+	_, ok = operator.peers[rsp3.Pubkey]
+	assert.Equal(t, false, ok)
+	_, ok = operator.peers[unknownKey.String()]
+	assert.Equal(t, true, ok)
+
+	// dry run 只输出计划
+	diffs, err = server.Reconcile(ctx, true)
+	assert.Equal(t, nil, err)
+	diff := diffs["wg0"]
+	assert.Equal(t, 1, len(diff.Add))
+	assert.Equal(t, rsp1.Pubkey, diff.Add[0].PublicKey.String())
+	assert.Equal(t, 1, len(diff.Update))
+	assert.Equal(t, rsp2.Pubkey, diff.Update[0].PublicKey.String())
+	assert.Equal(t, 1, len(diff.Remove))
+	assert.Equal(t, unknownKey, diff.Remove[0].PublicKey)
+	assert.Equal(t, 2, len(operator.peers))
+
+	// 修正设备
+	_, err = server.Reconcile(ctx, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 2, len(operator.peers))
+	_, ok = operator.peers[rsp1.Pubkey]
+	assert.Equal(t, true, ok)
+	_, ok = operator.peers[unknownKey.String()]
+	assert.Equal(t, false, ok)
+	assert.Equal(t, []string{"192.168.222.3/32"}, networksString(operator.peers[rsp2.Pubkey].PeerConfig.AllowedIPs))
+
+	diffs, err = server.Reconcile(ctx, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, diffs["wg0"].Empty())
+}
+
+func TestBootstrap(t *testing.T) {
+	clearDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+	network := services.NetworkConfig{
+		PeerName:          "relay",
+		InterfaceName:     "wg0",
+		Address:           resetRelayAddress,
+		ListenPort:        51820,
+		PublicIp:          "1.2.3.4",
+		KeepAliveInterval: 25,
+	}
+
+	// 第一次启动时创建中继节点
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+	relay, err := models.GetRelayPeer(testDb)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "relay", relay.PeerName)
+	assert.NotEqual(t, "", relay.PrivateKey)
+	assert.Equal(t, "wg0", operator.server.InterfaceName)
+	assert.Equal(t, relay.PrivateKey, operator.server.PrivateKey)
+	assert.Equal(t, 51820, operator.server.ListenPort)
+
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, relay.PublicKey, rsp.RelayPeerInfo[0].Pubkey)
+
+	// 模拟重启，wg 接口以及节点都丢失，设备上有手动添加的节点
+	operator = newFakeOperator()
+	_, unknownKey, _ := wg.GenerateWgKeyPairs()
+	operator.peers[unknownKey.String()] = wg.WgPeerConfig{
+		InterfaceName: "wg0",
+		PeerConfig:    wgtypes.PeerConfig{PublicKey: unknownKey},
+	}
+	server = services.NewServer(testDb, logger).WithWgOperator(operator)
+	network.PublicIp = "5.6.7.8"
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+	restarted, err := models.GetRelayPeer(testDb)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, relay.ID, restarted.ID)
+	assert.Equal(t, relay.PrivateKey, restarted.PrivateKey)
+	assert.Equal(t, "5.6.7.8", restarted.PublicIp)
+	assert.Equal(t, relay.PrivateKey, operator.server.PrivateKey)
+	_, ok := operator.peers[rsp.Pubkey]
+	assert.Equal(t, true, ok)
+	// 启动时不删除多余的节点，由 Reconcile 按照 dry run 的配置删除
+	_, ok = operator.peers[unknownKey.String()]
+	assert.Equal(t, true, ok)
+
+	// 不允许修改网络地址
+	network.Address, _ = inet.NewCidrAddressFromString("10.0.0.1/24")
+	assert.ErrorIs(t, server.Bootstrap(ctx, network), errs.WgNetworkChangedError)
+}
+
+func TestRegisterPeerWithPresharedKey(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+
+	// 默认不使用预共享密钥
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", rsp.RelayPeerInfo[0].PresharedKey)
+	assert.Nil(t, operator.peers[rsp.Pubkey].PeerConfig.PresharedKey)
+
+	// 节点要求使用预共享密钥
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node2", PeerType: pb.PeerType_P2P, PresharedKey: true})
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", rsp.RelayPeerInfo[0].PresharedKey)
+	assert.Equal(t, rsp.RelayPeerInfo[0].PresharedKey, operator.peers[rsp.Pubkey].PeerConfig.PresharedKey.String())
+	// 节点侧的配置也使用同一个预共享密钥
+	peer, err := models.GetPeerByName(testDb, "node2")
+	assert.Equal(t, nil, err)
+	config, err := peer.ToWgPeerConfig(testDb)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, rsp.RelayPeerInfo[0].PresharedKey, config.PeerConfig.PresharedKey.String())
+
+	// 服务端要求必须使用预共享密钥
+	server.WithPresharedKeyRequired(true)
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node3", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", rsp.RelayPeerInfo[0].PresharedKey)
+
+	diffs, err := server.Reconcile(ctx, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, diffs["wg0"].Empty())
+}
+
+func TestBootstrapBackfillPresharedKey(t *testing.T) {
+	clearDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+	network := services.NetworkConfig{
+		PeerName:          "relay",
+		InterfaceName:     "wg0",
+		Address:           resetRelayAddress,
+		ListenPort:        51820,
+		PublicIp:          "1.2.3.4",
+		KeepAliveInterval: 25,
+	}
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", rsp.RelayPeerInfo[0].PresharedKey)
+
+	// 开启强制预共享密钥之后重新启动，之前注册的节点也使用预共享密钥
+	server = services.NewServer(testDb, logger).WithWgOperator(operator).WithPresharedKeyRequired(true)
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+	peer, err := models.GetPeerByName(testDb, "node1")
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, "", peer.PresharedKey)
+	assert.Equal(t, peer.PresharedKey, operator.peers[rsp.Pubkey].PeerConfig.PresharedKey.String())
+	relay, err := models.GetRelayPeer(testDb)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "", relay.PresharedKey)
+}
+
+func TestSyncRoutes(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator).WithRouteConfig(100, 10)
+	_, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "site1",
+		PeerType: pb.PeerType_SubNet,
+		SubNets:  []*pb.CidrAddress{{Address: "10.1.0.0/24"}},
+	})
+	assert.Equal(t, nil, err)
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "site2",
+		PeerType: pb.PeerType_SubNet,
+		SubNets:  []*pb.CidrAddress{{Address: "10.2.0.0/24"}, {Address: "10.3.0.0/24"}},
+	})
+	assert.Equal(t, nil, err)
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.2.0.0/24", "10.3.0.0/24"}, operator.routesString())
+
+	// 修改子网地址后替换路由
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site2",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "10.4.0.0/24"}}},
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.1.0.0/24", "10.4.0.0/24"}, operator.routesString())
+
+	// 注销节点后删除路由
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "site1"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.4.0.0/24"}, operator.routesString())
+
+	// 路由被手动修改后由 Reconcile 修正，dry run 不修改路由
+	_, extra, _ := net.ParseCIDR("172.16.0.0/16")
+	operator.routes = map[string]net.IPNet{extra.String(): *extra}
+	_, err = server.Reconcile(ctx, true)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"172.16.0.0/16"}, operator.routesString())
+	_, err = server.Reconcile(ctx, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.4.0.0/24"}, operator.routesString())
+}
+
+func TestGetPeerConfig(t *testing.T) {
+	resetDb(t)
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+	reqs := []*pb.RegisterPeerReq{
+		{PeerName: "a", PeerType: pb.PeerType_P2P, Endpoint: "5.6.7.8:51820", Mesh: true, PresharedKey: true},
+		{PeerName: "b", PeerType: pb.PeerType_SubNet, SubNets: []*pb.CidrAddress{{Address: "10.1.0.0/24"}}, Mesh: true, PresharedKey: true},
+		{PeerName: "c", PeerType: pb.PeerType_P2P},
+		{PeerName: "d", PeerType: pb.PeerType_P2P, Mesh: true},
+	}
+	for _, req := range reqs {
+		_, err := server.RegisterPeer(ctx, req)
+		assert.Equal(t, nil, err)
+	}
+	_, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "e", PeerType: pb.PeerType_P2P, Endpoint: "5.6.7.8"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// 返回节点名以及对应的 AllowedIPs
+	peerConfig := func(topology models.Topology, name string) map[string]*pb.RemotePeer {
+		rsp, err := services.NewServer(testDb, logger).WithTopology(topology).
+			GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: name})
+		assert.Equal(t, nil, err)
+		return lo.SliceToMap(rsp.Peers, func(item *pb.RemotePeer) (string, *pb.RemotePeer) {
+			return item.PeerName, item
+		})
+	}
+	allowedIps := func(remote *pb.RemotePeer) []string {
+		return lo.Map(remote.AllowedIps, func(item *pb.CidrAddress, _ int) string {
+			return item.Address
+		})
+	}
+
+	// hub 所有流量都经过中继节点
+	peers := peerConfig(models.TopologyHub, "a")
+	assert.Equal(t, 1, len(peers))
+	assert.Equal(t, []string{"192.168.222.0/24", "10.1.0.0/24"}, allowedIps(peers["relay"]))
+	assert.Equal(t, "1.2.3.4:51820", peers["relay"].Endpoint)
+
+	// mesh 有公网端点的节点与所有节点直接连接
+	peers = peerConfig(models.TopologyMesh, "a")
+	assert.Equal(t, 4, len(peers))
+	assert.Equal(t, []string{"192.168.222.0/24"}, allowedIps(peers["relay"]))
+	assert.Equal(t, []string{"192.168.222.3/32", "10.1.0.0/24"}, allowedIps(peers["b"]))
+	assert.Equal(t, "", peers["b"].Endpoint)
+	assert.Equal(t, []string{"192.168.222.4/32"}, allowedIps(peers["c"]))
+	peers = peerConfig(models.TopologyMesh, "c")
+	assert.Equal(t, 2, len(peers))
+	assert.Equal(t, []string{"192.168.222.0/24", "10.1.0.0/24"}, allowedIps(peers["relay"]))
+	assert.Equal(t, "5.6.7.8:51820", peers["a"].Endpoint)
+
+	// hybrid 只有 mesh 节点直接连接
+	peers = peerConfig(models.TopologyHybrid, "a")
+	assert.Equal(t, []string{"relay", "b", "d"}, lo.Filter([]string{"relay", "b", "c", "d"}, func(item string, _ int) bool {
+		_, ok := peers[item]
+		return ok
+	}))
+	peers = peerConfig(models.TopologyHybrid, "c")
+	assert.Equal(t, 1, len(peers))
+
+	// 直接连接的两端使用相同的预共享密钥
+	pskAB := peerConfig(models.TopologyHybrid, "a")["b"].PresharedKey
+	pskBA := peerConfig(models.TopologyHybrid, "b")["a"].PresharedKey
+	assert.NotEqual(t, "", pskAB)
+	assert.Equal(t, pskAB, pskBA)
+	assert.NotEqual(t, peerConfig(models.TopologyHybrid, "a")["relay"].PresharedKey, pskAB)
+	assert.Equal(t, "", peerConfig(models.TopologyHybrid, "a")["d"].PresharedKey)
+
+	// 强制使用预共享密钥时，没有预共享密钥的节点不直接连接
+	required := services.NewServer(testDb, logger).WithTopology(models.TopologyMesh).WithPresharedKeyRequired(true)
+	rsp, err := required.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: "a"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"relay", "b"}, lo.Map(rsp.Peers, func(item *pb.RemotePeer, _ int) string {
+		return item.PeerName
+	}))
+	assert.Equal(t, pskAB, rsp.Peers[1].PresharedKey)
+	rsp, err = required.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: "d"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(rsp.Peers))
+
+	// 中继节点不能获取配置
+	_, err = server.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: "relay"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestMultipleRelays(t *testing.T) {
+	resetDb(t)
+	// relay2 由另外一个服务端启动，只会修改自己的 wg 设备
+	remoteOperator := newFakeOperator()
+	remote := services.NewServer(testDb, logger).WithWgOperator(remoteOperator)
+	relay2Address, _ := inet.NewCidrAddressFromString("192.168.222.254/24")
+	network := services.NetworkConfig{
+		PeerName:          "relay2",
+		InterfaceName:     "wg1",
+		Address:           relay2Address,
+		ListenPort:        51820,
+		PublicIp:          "2.3.4.5",
+		KeepAliveInterval: 25,
+	}
+	assert.Equal(t, nil, remote.Bootstrap(ctx, network))
+	used, err := models.NewDHCPStorage(testDb, resetRelayAddress).IsUsed(relay2Address.GetAddress())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, used)
+	// 中继节点必须在同一个网络中
+	otherNetwork := network
+	otherNetwork.PeerName = "relay3"
+	otherNetwork.Address, _ = inet.NewCidrAddressFromString("10.0.0.1/24")
+	assert.ErrorIs(t, remote.Bootstrap(ctx, otherNetwork), errs.WgNetworkChangedError)
+
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+	rsp1, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.2/24", rsp1.Address.Address)
+	assert.Equal(t, []string{"relay", "relay2"}, lo.Map(rsp1.RelayPeerInfo, func(item *pb.RelayPeerInfo, _ int) string {
+		return item.PeerName
+	}))
+	assert.Equal(t, "2.3.4.5:51820", rsp1.RelayPeerInfo[1].Endpoint)
+	rsp2, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName:   "node2",
+		PeerType:   pb.PeerType_SubNet,
+		SubNets:    []*pb.CidrAddress{{Address: "10.2.0.0/24"}},
+		RelayNames: []string{"relay2"},
+	})
+	assert.Equal(t, nil, err)
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node3", PeerType: pb.PeerType_P2P, RelayNames: []string{"node1"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	relay, _ := models.GetPeerByName(testDb, "relay")
+	relay2, _ := models.GetPeerByName(testDb, "relay2")
+	allowedIps := func(interfaceName, pubkey string) []string {
+		config, ok := operator.device(interfaceName)[pubkey]
+		assert.Equal(t, true, ok)
+		return networksString(config.PeerConfig.AllowedIPs)
+	}
+	// 只有当前使用的中继节点转发节点的流量，其他中继节点转发给当前使用的中继节点
+	assert.Equal(t, []string{"192.168.222.2/32"}, allowedIps("wg0", rsp1.Pubkey))
+	assert.Equal(t, []string{}, allowedIps("wg1", rsp1.Pubkey))
+	assert.Equal(t, []string{"192.168.222.3/32", "10.2.0.0/24"}, allowedIps("wg1", rsp2.Pubkey))
+	_, ok := operator.peers[rsp2.Pubkey]
+	assert.Equal(t, false, ok)
+	assert.Equal(t, []string{"192.168.222.254/32", "192.168.222.3/32", "10.2.0.0/24"}, allowedIps("wg0", relay2.PublicKey))
+	assert.Equal(t, []string{"192.168.222.1/32", "192.168.222.2/32"}, allowedIps("wg1", relay.PublicKey))
+	// 另外一个服务端只修改本地的中继节点
+	_, err = remote.Reconcile(ctx, false)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(remoteOperator.peers))
+	assert.Equal(t, 3, len(remoteOperator.device("wg1")))
+
+	// 节点没有设置保活时长时，与所有的中继节点之间仍然保活，否则无法判断中继节点是否可用
+	keepalive := int32(0)
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node1", KeepAliveInterval: &keepalive})
+	assert.Equal(t, nil, err)
+	config, err := server.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: "node1"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []int32{25, 25}, lo.Map(config.Peers, func(item *pb.RemotePeer, _ int) int32 {
+		return item.KeepAliveInterval
+	}))
+	// 只有一个中继节点时不强制保活
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node2", KeepAliveInterval: &keepalive})
+	assert.Equal(t, nil, err)
+	config, err = server.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: "node2"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(config.Peers))
+	assert.Equal(t, int32(0), config.Peers[0].KeepAliveInterval)
+
+	// 切换中继节点
+	config, err = server.SwitchRelay(ctx, &pb.SwitchRelayReq{PeerName: "node1", RelayName: "relay2"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "relay", config.Peers[0].PeerName)
+	assert.Equal(t, true, config.Peers[0].IsRelay)
+	assert.Equal(t, 0, len(config.Peers[0].AllowedIps))
+	assert.Equal(t, "relay2", config.Peers[1].PeerName)
+	assert.Equal(t, "192.168.222.0/24", config.Peers[1].AllowedIps[0].Address)
+	assert.Equal(t, []string{}, allowedIps("wg0", rsp1.Pubkey))
+	assert.Equal(t, []string{"192.168.222.2/32"}, allowedIps("wg1", rsp1.Pubkey))
+	assert.Equal(t, []string{"192.168.222.254/32", "192.168.222.2/32", "192.168.222.3/32", "10.2.0.0/24"},
+		allowedIps("wg0", relay2.PublicKey))
+	_, err = server.SwitchRelay(ctx, &pb.SwitchRelayReq{PeerName: "node2", RelayName: "relay"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// 注销后从所有的中继节点中删除
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node1"})
+	assert.Equal(t, nil, err)
+	_, ok = operator.peers[rsp1.Pubkey]
+	assert.Equal(t, false, ok)
+	_, ok = operator.device("wg1")[rsp1.Pubkey]
+	assert.Equal(t, false, ok)
+}
+
+func TestDualStack(t *testing.T) {
+	clearDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator)
+	network := services.NetworkConfig{
+		PeerName:          "relay",
+		InterfaceName:     "wg0",
+		Address:           resetRelayAddress,
+		ListenPort:        51820,
+		PublicIp:          "1.2.3.4",
+		KeepAliveInterval: 25,
+	}
+	// 地址的协议族必须匹配
+	network.Address6, _ = inet.NewCidrAddressFromString("10.0.0.1/24")
+	assert.ErrorIs(t, server.Bootstrap(ctx, network), errs.WgInvalidAddressError)
+	// 先以单栈启动，之后开启双栈
+	network.Address6 = inet.CidrAddress{}
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+	rsp1, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, (*pb.CidrAddress)(nil), rsp1.Address6)
+	network.Address6, _ = inet.NewCidrAddressFromString("fd00:222::1/64")
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+	assert.Equal(t, "fd00:222::1/64", operator.server.Address6.String())
+
+	rsp2, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node2", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.3/24", rsp2.Address.Address)
+	assert.Equal(t, "fd00:222::2/64", rsp2.Address6.Address)
+	assert.Equal(t, []string{"192.168.222.3/32", "fd00:222::2/128"},
+		networksString(operator.peers[rsp2.Pubkey].PeerConfig.AllowedIPs))
+	info, err := server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "node2"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00:222::2/64", info.Address6.Address)
+	config, err := server.GetPeerConfig(ctx, &pb.GetPeerConfigReq{PeerName: "node2"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "fd00:222::2/64", config.Address6.Address)
+	assert.Equal(t, []string{"192.168.222.0/24", "fd00:222::/64"}, lo.Map(config.Peers[0].AllowedIps,
+		func(item *pb.CidrAddress, _ int) string {
+			return item.Address
+		}))
+
+	// 开启双栈之后不允许修改
+	network.Address6, _ = inet.NewCidrAddressFromString("fd00:333::1/64")
+	assert.ErrorIs(t, server.Bootstrap(ctx, network), errs.WgNetworkChangedError)
+
+	// 注销后释放 IPv6 地址
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node2"})
+	assert.Equal(t, nil, err)
+	relay6, _ := inet.NewCidrAddressFromString("fd00:222::1/64")
+	used, err := models.NewDHCPStorage(testDb, relay6).IsUsed(net.ParseIP("fd00:222::2"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, used)
+}
+
+func TestKeyHashAllocation(t *testing.T) {
+	clearDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator).
+		WithAllocateStrategy(models.StrategySequential, models.StrategyKeyHash)
+	network := services.NetworkConfig{
+		PeerName:          "relay",
+		InterfaceName:     "wg0",
+		Address:           resetRelayAddress,
+		ListenPort:        51820,
+		PublicIp:          "1.2.3.4",
+		KeepAliveInterval: 25,
+	}
+	// 根据公钥生成地址需要足够大的网络
+	network.Address6, _ = inet.NewCidrAddressFromString("fd00:222::1/120")
+	assert.ErrorIs(t, server.Bootstrap(ctx, network), errs.WgInvalidStrategyError)
+	network.Address6, _ = inet.NewCidrAddressFromString("fd00:222::1/64")
+	assert.Equal(t, nil, server.Bootstrap(ctx, network))
+
+	_, pubKey, _ := wg.GenerateWgKeyPairs()
+	req := &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P, Pubkey: pubKey.String()}
+	rsp, err := server.RegisterPeer(ctx, req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.2/24", rsp.Address.Address)
+	address6 := rsp.Address6.Address
+	assert.NotEqual(t, "fd00:222::2/64", address6)
+	// 同样的公钥重新注册得到同样的地址
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node1"})
+	assert.Equal(t, nil, err)
+	rsp, err = server.RegisterPeer(ctx, req)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, address6, rsp.Address6.Address)
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	resetDb(t)
+	operator := newFakeOperator()
+	server := services.NewServer(testDb, logger).WithWgOperator(operator).WithLease(time.Hour, time.Hour)
+	rsp1, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Greater(t, rsp1.ExpireTime, time.Now().Unix())
+	rsp2, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node2", PeerType: pb.PeerType_P2P, Static: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(0), rsp2.ExpireTime)
+
+	renew, err := server.RenewLease(ctx, &pb.RenewLeaseReq{PeerName: "node1"})
+	assert.Equal(t, nil, err)
+	assert.GreaterOrEqual(t, renew.ExpireTime, rsp1.ExpireTime)
+	_, err = server.RenewLease(ctx, &pb.RenewLeaseReq{PeerName: "node3"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	// 没有到期时不会回收
+	reaped, err := server.ReapLeases(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(reaped))
+	// 模拟租约到期，静态节点不会被回收
+	assert.Equal(t, nil, testDb.Model(&models.DhcpClient{}).Where("enable = ?", true).
+		Update("expire_time", time.Now().Add(-time.Minute)).Error)
+	reaped, err = server.ReapLeases(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"node1"}, reaped)
+	_, ok := operator.peers[rsp1.Pubkey]
+	assert.Equal(t, false, ok)
+	_, ok = operator.peers[rsp2.Pubkey]
+	assert.Equal(t, true, ok)
+	_, err = models.GetPeerByName(testDb, "node1")
+	assert.ErrorIs(t, err, errs.WgPeerNotFoundError)
+	used, err := models.NewDHCPStorage(testDb, resetRelayAddress).IsUsed(net.ParseIP("192.168.222.2"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, used)
+	// 中继节点的地址不会被回收
+	used, err = models.NewDHCPStorage(testDb, resetRelayAddress).IsUsed(resetRelayAddress.GetAddress())
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, used)
+
+	// 取消静态节点之后需要续租
+	info, err := server.UpdatePeer(ctx, &pb.UpdatePeerReq{PeerName: "node2", Static: lo.ToPtr(false)})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, info.Static)
+	renew, err = server.RenewLease(ctx, &pb.RenewLeaseReq{PeerName: "node2"})
+	assert.Equal(t, nil, err)
+	assert.Greater(t, renew.ExpireTime, time.Now().Unix())
+}
+
+func TestReservations(t *testing.T) {
+	resetDb(t)
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+	_, pubKey, _ := wg.GenerateWgKeyPairs()
+	reservations := []*pb.Reservation{
+		{Key: &pb.Reservation_PeerName{PeerName: "router"}, Address: "192.168.222.2", Remark: "路由器"},
+		{Key: &pb.Reservation_Pubkey{Pubkey: pubKey.String()}, Address: "192.168.222.3"},
+		{Key: &pb.Reservation_HardwareAddr{HardwareAddr: "00:16:3e:03:57:45"}, Address: "192.168.222.4"},
+	}
+	for _, reservation := range reservations {
+		_, err := server.AddReservation(ctx, reservation)
+		assert.Equal(t, nil, err)
+	}
+	_, err := server.AddReservation(ctx, &pb.Reservation{Address: "192.168.222.5"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.AddReservation(ctx, &pb.Reservation{Key: &pb.Reservation_PeerName{PeerName: "monitor"}, Address: "192.168.222.1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.AddReservation(ctx, &pb.Reservation{Key: &pb.Reservation_PeerName{PeerName: "monitor"}, Address: "192.168.222.2"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+	_, err = server.AddReservation(ctx, &pb.Reservation{Key: &pb.Reservation_PeerName{PeerName: "monitor"}, Address: "fd00::1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	list, err := server.ListReservations(ctx, &pb.ListReservationsReq{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(list.Reservations))
+	assert.Equal(t, "router", list.Reservations[0].GetPeerName())
+	assert.Equal(t, "00:16:3e:03:57:45", list.Reservations[2].GetHardwareAddr())
+
+	// 预留的地址不会分配给其他节点
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.5/24", rsp.Address.Address)
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node2", PeerType: pb.PeerType_P2P, Pubkey: pubKey.String()})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.3/24", rsp.Address.Address)
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node3", PeerType: pb.PeerType_P2P, HardwareAddr: "00:16:3e:03:57:45"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.4/24", rsp.Address.Address)
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node4", PeerType: pb.PeerType_P2P, HardwareAddr: "invalid"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	// 重新注册之后仍然使用预留的地址
+	for i := 0; i < 2; i++ {
+		rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "router", PeerType: pb.PeerType_P2P})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "192.168.222.2/24", rsp.Address.Address)
+		_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "router"})
+		assert.Equal(t, nil, err)
+	}
+
+	_, err = server.DeleteReservation(ctx, &pb.DeleteReservationReq{Address: "192.168.222.2"})
+	assert.Equal(t, nil, err)
+	_, err = server.DeleteReservation(ctx, &pb.DeleteReservationReq{Address: "192.168.222.2"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestAddressPool(t *testing.T) {
+	resetDb(t)
+	pool, err := models.ParsePoolConfig("192.168.222.250", "", []string{"192.168.222.252"})
+	assert.Equal(t, nil, err)
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator()).
+		WithAddressPool(pool, models.PoolConfig{})
+
+	// 只在地址池的范围中分配，不会分配广播地址
+	var addresses []string
+	for i := 0; i < 4; i++ {
+		rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: fmt.Sprintf("node%d", i), PeerType: pb.PeerType_P2P})
+		assert.Equal(t, nil, err)
+		addresses = append(addresses, rsp.Address.Address)
+	}
+	assert.Equal(t, []string{"192.168.222.250/24", "192.168.222.251/24", "192.168.222.253/24", "192.168.222.254/24"}, addresses)
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node4", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	rsp, err := server.GetPoolStats(ctx, &pb.GetPoolStatsReq{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, len(rsp.Pools))
+	assert.Equal(t, "192.168.222.0/24", rsp.Pools[0].Network)
+	assert.Equal(t, "192.168.222.250", rsp.Pools[0].First)
+	assert.Equal(t, "192.168.222.254", rsp.Pools[0].Last)
+	assert.Equal(t, uint64(4), rsp.Pools[0].Total)
+	assert.Equal(t, uint64(4), rsp.Pools[0].Used)
+	assert.Equal(t, uint64(0), rsp.Pools[0].Free)
+
+	// 释放之后可以重新分配
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "node1"})
+	assert.Equal(t, nil, err)
+	rsp, err = server.GetPoolStats(ctx, &pb.GetPoolStatsReq{})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, uint64(1), rsp.Pools[0].Free)
+	peer, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node4", PeerType: pb.PeerType_P2P})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "192.168.222.251/24", peer.Address.Address)
+
+	// 地址池的范围必须在网络中
+	pool, err = models.ParsePoolConfig("192.168.1.1", "", nil)
+	assert.Equal(t, nil, err)
+	err = services.NewServer(testDb, logger).WithWgOperator(newFakeOperator()).WithAddressPool(pool, models.PoolConfig{}).
+		Bootstrap(ctx, services.NetworkConfig{
+			PeerName:      "relay",
+			InterfaceName: "wg0",
+			Address:       resetRelayAddress,
+			ListenPort:    51820,
+			PublicIp:      "1.2.3.4",
+		})
+	assert.ErrorIs(t, err, errs.WgInvalidPoolError)
+}
+
+func TestAllocateSubnet(t *testing.T) {
+	resetDb(t)
+	_, supernet, _ := net.ParseCIDR("10.100.0.0/16")
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+
+	// 没有配置超网时不能分配子网
+	_, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "site1", PeerType: pb.PeerType_SubNet, AllocateSubnet: true})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	server.WithSubnetPool(*supernet, 24)
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "node1", PeerType: pb.PeerType_P2P, AllocateSubnet: true})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "site1", PeerType: pb.PeerType_SubNet, AllocateSubnet: true, SubnetPrefix: 8})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName:       "site1",
+		PeerType:       pb.PeerType_SubNet,
+		SubNets:        []*pb.CidrAddress{{Address: "10.100.0.1/24"}},
+		AllocateSubnet: true,
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.0.0/24", rsp.SubNets[0].Address)
+	assert.Equal(t, "10.100.1.0/24", rsp.SubNets[1].Address)
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "site2", PeerType: pb.PeerType_SubNet, AllocateSubnet: true, SubnetPrefix: 22})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.4.0/22", rsp.SubNets[0].Address)
+	info, err := server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "site2"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.4.0/22", info.SubNets[0].Address)
+
+	// 注销之后释放分配的子网
+	_, err = server.UnregisterPeer(ctx, &pb.UnregisterPeerReq{PeerName: "site1"})
+	assert.Equal(t, nil, err)
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "site3", PeerType: pb.PeerType_SubNet, AllocateSubnet: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.0.0/24", rsp.SubNets[0].Address)
+
+	// 修改子网地址之后释放不再使用的子网，保留仍然在使用的子网
+	storage := models.NewSubnetStorage(testDb, *supernet, 24)
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site3",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "192.168.10.1/24"}}},
+	})
+	assert.Equal(t, nil, err)
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site2",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "10.100.4.0/23"}, {Address: "192.168.20.1/24"}}},
+	})
+	assert.Equal(t, nil, err)
+	allocations, err := storage.GetSubnetAllocations()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.100.4.0/22"}, lo.Map(allocations, func(item models.SubnetAllocation, _ int) string {
+		return item.Network.String()
+	}))
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site2",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "192.168.20.1/24"}}},
+	})
+	assert.Equal(t, nil, err)
+	allocations, err = storage.GetSubnetAllocations()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, len(allocations))
+	rsp, err = server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "site4", PeerType: pb.PeerType_SubNet, AllocateSubnet: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.0.0/24", rsp.SubNets[0].Address)
+}
+
+func TestSubnetConflicts(t *testing.T) {
+	resetDb(t)
+	_, lan, _ := net.ParseCIDR("10.100.0.0/24")
+	_, supernet, _ := net.ParseCIDR("10.100.0.0/16")
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator()).
+		WithDeniedSubnets([]net.IPNet{*lan}).WithSubnetPool(*supernet, 24)
+	register := func(name string, subnets ...string) error {
+		_, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+			PeerName: name,
+			PeerType: pb.PeerType_SubNet,
+			SubNets: lo.Map(subnets, func(item string, _ int) *pb.CidrAddress {
+				return &pb.CidrAddress{Address: item}
+			}),
+		})
+		return err
+	}
+
+	assert.Equal(t, nil, register("site1", "10.0.0.1/24"))
+	err := register("site2", "10.0.0.1/25")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "site1")
+	assert.Contains(t, err.Error(), "10.0.0.0/24")
+	err = register("site2", "192.168.222.128/25")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "192.168.222.0/24")
+	err = register("site2", "10.100.0.1/24")
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "10.100.0.0/24")
+	assert.Equal(t, nil, register("site2", "10.0.1.1/24"))
+
+	// 修改子网时同样校验
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site2",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "10.0.0.0/16"}}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site1",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "10.0.0.0/23"}}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site1",
+		SubNets:  &pb.SubnetList{SubNets: []*pb.CidrAddress{{Address: "10.0.0.0/25"}, {Address: "10.0.0.128/25"}}},
+	})
+	assert.Equal(t, nil, err)
+
+	// 分配子网时跳过禁止使用的网络
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{PeerName: "site3", PeerType: pb.PeerType_SubNet, AllocateSubnet: true})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "10.100.1.0/24", rsp.SubNets[0].Address)
+}
+
+func TestNormalizeSubnets(t *testing.T) {
+	resetDb(t)
+	server := services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+	toPb := func(subnets ...string) []*pb.CidrAddress {
+		return lo.Map(subnets, func(item string, _ int) *pb.CidrAddress {
+			return &pb.CidrAddress{Address: item}
+		})
+	}
+	toString := func(subnets []*pb.CidrAddress) []string {
+		return lo.Map(subnets, func(item *pb.CidrAddress, _ int) string {
+			return item.GetAddress()
+		})
+	}
+
+	// 清除主机位、去掉重复以及被包含的子网，并合并相邻的子网
+	rsp, err := server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+		PeerName: "site1",
+		PeerType: pb.PeerType_SubNet,
+		SubNets:  toPb("10.192.10.1/23", "10.192.10.0/24", "10.192.12.0/23", "10.192.11.7/32", "10.192.10.1/23"),
+	})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.192.10.0/23", "10.192.12.0/23"}, toString(rsp.SubNets))
+	info, err := server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "site1"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.192.10.0/23", "10.192.12.0/23"}, toString(info.SubNets))
+
+	_, err = server.UpdatePeer(ctx, &pb.UpdatePeerReq{
+		PeerName: "site1",
+		SubNets:  &pb.SubnetList{SubNets: toPb("10.192.9.0/24", "10.192.8.0/24", "10.192.10.0/23")},
+	})
+	assert.Equal(t, nil, err)
+	info, err = server.GetPeer(ctx, &pb.GetPeerReq{Key: &pb.GetPeerReq_PeerName{PeerName: "site1"}})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []string{"10.192.8.0/22"}, toString(info.SubNets))
+}
+
+func TestConcurrentRegister(t *testing.T) {
+	const count = 200
+	resetDb(t)
+	// 多个服务端共用同一个数据库，各自的锁不能保证地址不会重复分配
+	servers := make([]*services.Server, 4)
+	for i := range servers {
+		servers[i] = services.NewServer(testDb, logger).WithWgOperator(newFakeOperator())
+	}
+	var wg sync.WaitGroup
+	rsps := make([]*pb.RegisterPeerRsp, count)
+	results := make([]error, count)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			server := servers[i%len(servers)]
+			rsps[i], results[i] = server.RegisterPeer(ctx, &pb.RegisterPeerReq{
+				PeerName: fmt.Sprintf("node%d", i),
+				PeerType: pb.PeerType_P2P,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	addresses := make(map[string]bool)
+	for i := 0; i < count; i++ {
+		assert.Equal(t, nil, results[i])
+		if results[i] != nil {
+			continue
+		}
+		assert.Equal(t, false, addresses[rsps[i].Address.Address], rsps[i].Address.Address)
+		addresses[rsps[i].Address.Address] = true
+	}
+	var peers []models.Peer
+	assert.Equal(t, nil, testDb.Where("is_server = ?", false).Find(&peers).Error)
+	assert.Equal(t, count, len(lo.UniqBy(peers, func(item models.Peer) string {
+		return item.PeerAddress.String()
+	})))
+}
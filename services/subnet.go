@@ -0,0 +1,24 @@
+package services
+
+import (
+	"slices"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/inet"
+	"github.com/onesaltedseafish/wg-tool/models"
+	"gorm.io/gorm"
+)
+
+// allocateSubnet 从超网中为节点分配子网，返回追加了分配的子网之后节点的子网地址
+// 节点需要已经创建，分配的子网在节点注销时释放
+func (s *Server) allocateSubnet(tx *gorm.DB, peer models.Peer, prefix int) (inet.SubnetAddresses, error) {
+	if s.supernet.IP == nil {
+		return peer.PeerSubnetAddress, errs.WgNoSupernetError
+	}
+	network, err := models.NewSubnetStorage(tx, s.supernet, s.subnetSize).WithDenied(s.denied).AllocateSubnet(peer.PeerName, prefix)
+	if err != nil {
+		return peer.PeerSubnetAddress, err
+	}
+	addresses := append(slices.Clone(peer.PeerSubnetAddress.GetAddresses()), inet.NewCidrAddress(network.IP, network))
+	return inet.NewSubnetAddresses(addresses...), nil
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"go.uber.org/zap"
+	"golang.zx2c4.com/wireguard/wgctrl/wgtypes"
+	"gorm.io/gorm"
+)
+
+// UnregisterPeer 注销一个节点
+// 从中继节点的 wg 设备中删除节点，释放节点的地址以及分配的子网并删除节点记录
+// wg 设备以及数据库要么都修改成功，要么都保持不变
+func (s *Server) UnregisterPeer(ctx context.Context, req *pb.UnregisterPeerReq) (*pb.EmptyRsp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peer, err := s.removePeer(ctx, req.PeerName)
+	if err != nil {
+		s.logger.Error(ctx, "unregister peer failed", zap.String("peer", req.PeerName), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	s.logger.Info(ctx, "unregister peer", zap.String("peer", peer.PeerName),
+		zap.String("address", peer.PeerAddress.String()), zap.String("pubkey", peer.PublicKey))
+	// 从备用的中继节点中删除节点，并删除节点子网的路由
+	s.reconcileAfterChange(ctx, peer.PublicKey)
+	return &pb.EmptyRsp{}, nil
+}
+
+// removePeer 删除节点，调用方需要持有锁
+func (s *Server) removePeer(ctx context.Context, peerName string) (models.Peer, error) {
+	var relay, peer models.Peer
+	var pubKey wgtypes.Key
+	var removed bool // wg 设备中的节点是否已经被删除
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if peer, err = models.GetPeerByName(tx, peerName); err != nil {
+			return err
+		}
+		if peer.IsServer {
+			return fmt.Errorf("%w: %s", errs.WgRelayPeerError, peer.PeerName)
+		}
+		if relay, err = peer.GetConnectPeer(tx); err != nil {
+			return err
+		}
+		if pubKey, err = wgtypes.ParseKey(peer.PublicKey); err != nil {
+			return err
+		}
+		if err = releaseAddress(tx, peer); err != nil {
+			return err
+		}
+		if err = models.ReleasePeerSubnets(tx, peer.PeerName); err != nil {
+			return err
+		}
+		if err = models.DeletePeerRelays(tx, peer.ID); err != nil {
+			return err
+		}
+		if err = tx.Delete(&peer).Error; err != nil {
+			return err
+		}
+		if !s.isLocalRelay(relay) {
+			return nil
+		}
+		// 最后修改 wg 设备，失败时数据库中的修改会被回滚
+		if err = s.operator.RemovePeer(relay.InterfaceName, pubKey); err != nil {
+			return err
+		}
+		removed = true
+		return nil
+	})
+	if err != nil && removed {
+		// 数据库提交失败，将节点重新添加回 wg 设备
+		if restoreErr := s.restorePeer(relay, peer); restoreErr != nil {
+			s.logger.Error(ctx, "restore peer failed", zap.String("peer", peer.PeerName), zap.Error(restoreErr))
+		}
+	}
+	return peer, err
+}
+
+// restorePeer 将节点重新添加回中继节点的 wg 设备
+func (s *Server) restorePeer(relay, peer models.Peer) error {
+	config, err := peer.ToWgRelayPeerConfig(relay)
+	if err != nil {
+		return err
+	}
+	return s.operator.AddPeer(config)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/onesaltedseafish/wg-tool/commons/errs"
+	"github.com/onesaltedseafish/wg-tool/commons/wg"
+	"github.com/onesaltedseafish/wg-tool/models"
+	pb "github.com/onesaltedseafish/wg-tool/protocols"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// UpdatePeer 修改节点的子网地址、保活时长、备注、节点类型、直接连接以及静态节点的配置
+// 修改会同时应用到中继节点的 wg 设备上，地址和密钥保持不变
+func (s *Server) UpdatePeer(ctx context.Context, req *pb.UpdatePeerReq) (*pb.PeerInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var relay, peer models.Peer
+	var oldConfig wg.WgPeerConfig
+	var updated bool // wg 设备中的节点是否已经被修改
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if peer, err = models.GetPeerByName(tx, req.PeerName); err != nil {
+			return err
+		}
+		if peer.IsServer {
+			return fmt.Errorf("%w: %s", errs.WgRelayPeerError, peer.PeerName)
+		}
+		if relay, err = peer.GetConnectPeer(tx); err != nil {
+			return err
+		}
+		// 修改之前的配置，数据库提交失败时用于恢复 wg 设备
+		if oldConfig, err = peer.ToWgRelayPeerConfig(relay); err != nil {
+			return err
+		}
+		if err = applyUpdatePeerReq(&peer, req); err != nil {
+			return err
+		}
+		if req.SubNets != nil {
+			if err = models.CheckSubnetConflicts(tx, peer.PeerName, peer.PeerSubnetAddress.GetNetworks(), s.denied); err != nil {
+				return err
+			}
+			// 不再使用的子网归还到超网中，可以分配给其他节点
+			if err = models.ReleaseUnusedSubnets(tx, peer.PeerName, peer.PeerSubnetAddress.GetAddresses()); err != nil {
+				return err
+			}
+		}
+		config, err := peer.ToWgRelayPeerConfig(relay)
+		if err != nil {
+			return err
+		}
+		if err = tx.Save(&peer).Error; err != nil {
+			return err
+		}
+		// 按照新的租约时长续租，静态节点的租约不会过期
+		if req.Static != nil {
+			if _, err = s.renewLease(tx, peer); err != nil {
+				return err
+			}
+		}
+		if !s.isLocalRelay(relay) {
+			return nil
+		}
+		// 最后修改 wg 设备，失败时数据库中的修改会被回滚
+		if err = s.operator.UpdatePeer(config); err != nil {
+			return err
+		}
+		updated = true
+		return nil
+	})
+	if err != nil && updated {
+		// 数据库提交失败，将 wg 设备中的节点恢复为修改之前的配置
+		if restoreErr := s.operator.UpdatePeer(oldConfig); restoreErr != nil {
+			s.logger.Error(ctx, "restore peer failed", zap.String("peer", req.PeerName), zap.Error(restoreErr))
+		}
+	}
+	if err != nil {
+		s.logger.Error(ctx, "update peer failed", zap.String("peer", req.PeerName), zap.Error(err))
+		return nil, toStatusError(err)
+	}
+	s.logger.Info(ctx, "update peer", zap.String("peer", peer.PeerName),
+		zap.String("subnets", peer.PeerSubnetAddress.String()), zap.Int("keepalive", peer.KeepAliveInterval))
+	// 子网地址变化后同步其他中继节点以及路由
+	s.reconcileAfterChange(ctx, peer.PublicKey)
+	return toPeerInfo(peer), nil
+}
+
+// applyUpdatePeerReq 将请求中设置了的字段应用到节点上，并校验修改后的节点
+func applyUpdatePeerReq(peer *models.Peer, req *pb.UpdatePeerReq) error {
+	if req.PeerType != nil {
+		if req.GetPeerType() != pb.PeerType_P2P && req.GetPeerType() != pb.PeerType_SubNet {
+			return fmt.Errorf("%w: %s", errs.WgInvalidPeerTypeError, req.GetPeerType())
+		}
+		peer.PeerType = uint(req.GetPeerType())
+	}
+	if req.SubNets != nil {
+		subnets, err := parseSubnets(req.SubNets.SubNets)
+		if err != nil {
+			return err
+		}
+		peer.PeerSubnetAddress = subnets
+	}
+	if req.KeepAliveInterval != nil {
+		// wg 设备中的保活时长为 uint16，超出范围时会被截断
+		if req.GetKeepAliveInterval() < 0 || req.GetKeepAliveInterval() > math.MaxUint16 {
+			return fmt.Errorf("%w: %d", errs.WgInvalidKeepAliveError, req.GetKeepAliveInterval())
+		}
+		peer.KeepAliveInterval = int(req.GetKeepAliveInterval())
+	}
+	if req.Remark != nil {
+		peer.Remark = req.GetRemark()
+	}
+	if req.Mesh != nil {
+		peer.Mesh = req.GetMesh()
+	}
+	if req.Static != nil {
+		peer.Static = req.GetStatic()
+	}
+	if req.Endpoint != nil {
+		publicIp, listenPort, err := parseEndpoint(req.GetEndpoint())
+		if err != nil {
+			return err
+		}
+		peer.PublicIp, peer.ListenPort = publicIp, listenPort
+	}
+	if peer.PeerType == uint(pb.PeerType_SubNet) && len(peer.PeerSubnetAddress.GetAddresses()) == 0 {
+		return fmt.Errorf("%w: SubNet 节点需要指定子网地址", errs.WgInvalidAddressError)
+	}
+	return nil
+}